@@ -0,0 +1,59 @@
+package haozpay
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// BaseURLFunc 按请求选择网关地址
+// 参数为请求上下文和请求的商户编号，返回空字符串时使用 Config 中的 BaseURL
+type BaseURLFunc func(ctx context.Context, merchantNo string) string
+
+// baseURLMiddleware 按请求选择网关地址的中间件
+// 将相对路径改写为 BaseURLFunc 返回的地址加路径，resty 不再拼接默认的 BaseURL；
+// 重试时请求地址已是绝对地址，不会重复选择
+//
+// 参数:
+//   - resolve: 网关地址选择函数
+//
+// 返回:
+//   - resty.RequestMiddleware: resty 请求中间件函数
+func baseURLMiddleware(resolve BaseURLFunc) resty.RequestMiddleware {
+	return func(c *resty.Client, r *resty.Request) error {
+		if isAbsoluteURL(r.URL) {
+			return nil
+		}
+
+		// GET 接口的商户编号在查询参数中
+		merchantNo := r.QueryParam.Get("merchantNo")
+		if haozReq, ok := r.Body.(*HaozPayRequest); ok {
+			merchantNo = haozReq.MerchantNo
+		}
+
+		baseURL := resolve(r.Context(), merchantNo)
+		if baseURL == "" {
+			return nil
+		}
+		if err := validateBaseURL(baseURL); err != nil {
+			return fmt.Errorf("failed to resolve base URL for merchant %s: %w", merchantNo, err)
+		}
+		r.URL = strings.TrimRight(baseURL, "/") + r.URL
+		return nil
+	}
+}
+
+// isAbsoluteURL 判断请求地址是否已包含协议和主机
+func isAbsoluteURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://")
+}
+
+// requestURL 返回请求实际发送的地址，相对路径拼接客户端的 BaseURL
+func requestURL(c *resty.Client, r *resty.Request) string {
+	if isAbsoluteURL(r.URL) {
+		return r.URL
+	}
+	return c.BaseURL + r.URL
+}
@@ -1,14 +1,24 @@
 package haozpay
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
 	"github.com/go-resty/resty/v2"
 )
 
 const (
 	// SDKVersion SDK 版本号
 	SDKVersion = "1.0.0"
-	// UserAgent HTTP 请求的 User-Agent 标识
-	UserAgent = "haozPay/" + SDKVersion
+	// UserAgent HTTP 请求的默认 User-Agent 标识，可通过 Config.AppName 追加应用标识
+	UserAgent = "haozpay-sdk-go/" + SDKVersion
 )
 
 // Client SDK 客户端，提供皓臻支付业务服务的访问入口
@@ -18,6 +28,10 @@ type Client struct {
 	config *Config
 	// restyClient 底层 HTTP 客户端
 	restyClient *resty.Client
+	// keys 解析后的商户私钥和平台公钥缓存
+	keys *keyCache
+	// closeOnce 保证 Close 只执行一次
+	closeOnce sync.Once
 
 	// Payment 支付服务，提供皓臻支付相关的 API 操作
 	// 包含统一下单、订单取消、退款、退款查询、账户提现等功能
@@ -39,6 +53,9 @@ type Client struct {
 //   - 注册请求签名和日志中间件
 //   - 初始化支付服务
 //
+// 注意:
+//   - 密钥在首次使用时才会解析，如需降低首个请求的延迟，可调用 Warmup 预热
+//
 // 示例:
 //
 //	config := sdk.DefaultConfig().
@@ -62,35 +79,114 @@ func NewClient(cfg *Config) (*Client, error) {
 
 	// 创建并配置底层 HTTP 客户端
 	restyClient := resty.New().
-		SetBaseURL(cfg.BaseURL).                      // 设置 API 基础地址
+		SetBaseURL(cfg.baseURL()).                    // 设置 API 基础地址
 		SetTimeout(cfg.Timeout).                      // 设置请求超时时间
 		SetDebug(cfg.Debug).                          // 设置调试模式
 		SetRetryCount(cfg.RetryCount).                // 设置重试次数
-		SetRetryWaitTime(cfg.RetryWaitTime).          // 设置重试等待时间
+		SetRetryWaitTime(0).                          // 等待时间由 retryAfter 计算，不设置下限
 		SetRetryMaxWaitTime(cfg.RetryMaxWait).        // 设置最大重试等待时间
-		SetHeader("User-Agent", UserAgent).           // 设置 User-Agent
+		AddRetryCondition(retryCondition).            // 网络错误和 429 时重试
+		SetRetryAfter(retryAfter(cfg.backoff())).     // 按 Retry-After 响应头或指数退避等待
+		AddRetryHook(closeUnreadBody).                // 重试前关闭流式请求未读取的响应体
+		SetHeader("User-Agent", cfg.userAgent()).     // 设置 User-Agent
 		SetHeader("Content-Type", "application/json") // 设置内容类型
 
+	// resty 的调试日志超出限制时只打印大小
+	if cfg.DebugBodyLimit > 0 {
+		restyClient.SetDebugBodyLimit(int64(cfg.DebugBodyLimit))
+	}
+
+	// 应用连接池和建连超时参数
+	if transport, err := restyClient.Transport(); err == nil {
+		if cfg.MaxIdleConns > 0 {
+			transport.MaxIdleConns = cfg.MaxIdleConns
+		}
+		if cfg.MaxIdleConnsPerHost > 0 {
+			transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+		}
+		if cfg.IdleConnTimeout > 0 {
+			transport.IdleConnTimeout = cfg.IdleConnTimeout
+		}
+		if cfg.DialTimeout > 0 {
+			transport.DialContext = (&net.Dialer{
+				Timeout:   cfg.DialTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext
+		}
+		if cfg.TLSHandshakeTimeout > 0 {
+			transport.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+		}
+	}
+
+	// 配置了响应体大小上限时，由 resty 在读取响应体时检查，成功和错误响应都受限制
+	if cfg.MaxResponseBytes > 0 {
+		restyClient.SetResponseBodyLimit(cfg.MaxResponseBytes)
+	}
+
 	// 如果配置了代理，则设置代理
 	if cfg.Proxy != "" {
 		restyClient.SetProxy(cfg.Proxy)
 	}
 
 	// 如果配置了 TLS，则应用 TLS 配置
-	if cfg.TLSConfig != nil {
-		restyClient.SetTLSClientConfig(cfg.TLSConfig)
+	tlsConfig, err := cfg.tlsClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		restyClient.SetTLSClientConfig(tlsConfig)
+		// 跳过证书校验只应用于本地调试，输出警告避免误用于生产环境
+		if tlsConfig.InsecureSkipVerify {
+			log.Printf("[SDK Warning] TLS certificate verification is disabled (InsecureSkipVerify), do not use this in production")
+		}
+	}
+
+	// 密钥在首次使用时解析并缓存，也可以通过 Warmup 提前解析
+	keys := newKeyCache(cfg.PrivateKey, cfg.PlatFormPublicKey, cfg.minKeyBits())
+
+	// 配置了限流时，首先注册限流中间件，超出速率的请求在签名前等待
+	if cfg.RateLimit > 0 {
+		restyClient.OnBeforeRequest(rateLimitMiddleware(newTokenBucket(cfg.RateLimit, cfg.RateLimitBurst)))
 	}
 
 	// 注册请求和响应中间件
-	restyClient.OnBeforeRequest(requestLogMiddleware(cfg.Debug))    // 请求日志中间件（调试模式时打印请求详情）
-	restyClient.OnBeforeRequest(signatureMiddleware(cfg.PrivateKey)) // 请求签名中间件（使用RSA私钥自动签名）
-	restyClient.OnAfterResponse(responseLogMiddleware(cfg.Debug))   // 响应日志中间件（调试模式时打印响应详情）
-	restyClient.OnAfterResponse(errorHandlerMiddleware())           // 错误处理中间件（统一处理错误响应）
+	signMiddleware := signatureMiddleware(cfg.signer(keys), cfg.FallbackSigner, cfg.Debug, cfg.SignatureLocation, cfg.signatureHeader(), cfg.SignHeaders)
+	restyClient.OnBeforeRequest(requestIDMiddleware(cfg)) // 请求ID中间件（透传上下文中的请求ID）
+
+	// 配置了网关地址选择函数时，按请求改写请求地址
+	if cfg.BaseURLFunc != nil {
+		restyClient.OnBeforeRequest(baseURLMiddleware(cfg.BaseURLFunc))
+	}
+
+	// 用户中间件按签名前后分别注册，修改请求体的中间件必须在签名之前
+	for _, middleware := range cfg.BeforeSignMiddlewares {
+		restyClient.OnBeforeRequest(middleware)
+	}
+	restyClient.OnBeforeRequest(requestLogMiddleware(cfg.Debug, cfg.requestIDHeader(), cfg.DebugBodyLimit)) // 请求日志中间件（调试模式时打印请求详情）
+	restyClient.OnBeforeRequest(signMiddleware)                                                             // 请求签名中间件（使用RSA私钥自动签名）
+	for _, middleware := range cfg.AfterSignMiddlewares {
+		restyClient.OnBeforeRequest(middleware)
+	}
+	restyClient.OnBeforeRequest(dryRunMiddleware(cfg.SignHeaders)) // 试运行中间件（试运行时签名后中止请求）
+	restyClient.OnBeforeRequest(formEncodingMiddleware())          // 表单编码中间件（按请求选项将请求体改为表单）
+
+	// 配置了压缩阈值时，注册请求体压缩中间件（必须在签名之后，表单请求不压缩）
+	if cfg.GzipThreshold > 0 {
+		restyClient.OnBeforeRequest(gzipMiddleware(cfg.GzipThreshold))
+	}
+	restyClient.OnAfterResponse(responseLogMiddleware(cfg.Debug, cfg.requestIDHeader(), cfg.DebugBodyLimit)) // 响应日志中间件（调试模式时打印响应详情）
+	restyClient.OnAfterResponse(errorHandlerMiddleware())                                                    // 错误处理中间件（统一处理错误响应）
+
+	// 开启响应验签时，注册响应签名校验中间件
+	if cfg.VerifyResponses {
+		restyClient.OnAfterResponse(responseSignatureMiddleware(cfg.verifier(keys), cfg.Debug, cfg.SignHeaders, cfg.RequireResponseSignature))
+	}
 
 	// 创建客户端实例
 	client := &Client{
 		config:      cfg,
 		restyClient: restyClient,
+		keys:        keys,
 	}
 
 	// 初始化支付服务
@@ -118,6 +214,20 @@ func (c *Client) GetConfig() *Config {
 	return c.config
 }
 
+// Stats 返回各接口路径的响应耗时统计快照
+//
+// 返回:
+//   - map[string]LatencyStats: 以接口路径为键的耗时统计，未开启 Config.CollectLatencyStats 时返回 nil
+//
+// 注意:
+//   - 统计从客户端创建开始累计，不会自动清零
+func (c *Client) Stats() map[string]LatencyStats {
+	if c.Payment.latency == nil {
+		return nil
+	}
+	return c.Payment.latency.snapshot()
+}
+
 // GetRestyClient 获取底层的 resty HTTP 客户端
 // 高级用户可以使用此方法获取底层客户端进行自定义操作
 //
@@ -145,6 +255,8 @@ func (c *Client) GetRestyClient() *resty.Client {
 //
 // 返回:
 //   - error: 验签失败时返回错误，成功返回 nil
+//     配置了 MaxClockSkew 时，时间戳超出范围返回 ErrNotificationExpired
+//     配置了 NonceCache 时，重复投递返回 ErrDuplicateNotification，此时应直接应答成功
 //
 // 使用场景:
 //   - 支付成功回调验签
@@ -173,5 +285,206 @@ func (c *Client) GetRestyClient() *resty.Client {
 //	// 签名验证通过，处理业务逻辑
 //	log.Println("回调签名验证成功")
 func (c *Client) VerifyCallback(params map[string]string, signature string) error {
-	return verifyHaozPaySignature(c.config.PlatFormPublicKey, params, signature)
-}
\ No newline at end of file
+	if err := verifyHaozPaySignature(c.config.verifier(c.keys), params, signature); err != nil {
+		return err
+	}
+
+	// 签名通过后再检查时间戳和重复投递，避免伪造的回调写入去重缓存
+	if c.config.MaxClockSkew > 0 {
+		if err := checkNotificationFreshness(params, c.config.now(), c.config.MaxClockSkew); err != nil {
+			return err
+		}
+	}
+	if c.config.NonceCache != nil {
+		return checkNotificationNonce(c.config.NonceCache, params, c.config.MaxClockSkew)
+	}
+	return nil
+}
+
+// SetPrivateKey 在运行时替换商户私钥
+// 用于私钥轮换，长期运行的服务无需重启即可使用新私钥签名
+//
+// 参数:
+//   - privateKeyPEM: 新的商户私钥（支持纯私钥字符串或完整PEM格式）
+//
+// 返回:
+//   - error: 私钥无法解析时返回 ErrKeyParse 类错误，此时继续使用原私钥
+//
+// 注意:
+//   - 可与请求并发调用，替换前已开始签名的请求使用原私钥，之后的请求使用新私钥
+//   - 配置了 Config.Signer 时签名不使用 PEM 私钥，调用会返回错误
+//   - 新私钥对应的公钥需要提前在平台登记，可先用 VerifyKeyPair 校验
+//
+// 示例:
+//
+//	if err := client.SetPrivateKey(newPrivateKeyPEM); err != nil {
+//	    log.Printf("私钥轮换失败: %v", err)
+//	}
+func (c *Client) SetPrivateKey(privateKeyPEM string) error {
+	if c.config.Signer != nil {
+		return ErrInvalidConfig("SetPrivateKey cannot be used when Signer is set")
+	}
+	if err := c.keys.setPrivateKey(privateKeyPEM); err != nil {
+		return newLocalizedError(ErrKeyParse, msgParsePrivateKey, err)
+	}
+	return nil
+}
+
+// Warmup 预热客户端，降低首个请求的延迟
+// 并发执行以下操作，全部完成后返回:
+//   - 解析并缓存商户私钥
+//   - 解析并缓存平台公钥
+//   - 与 BaseURL 建立连接并放入连接池，供后续请求复用
+//
+// 开启 Config.WarmupPing 时，以上操作全部成功后再调用 Ping 检查商户凭证
+//
+// 参数:
+//   - ctx: 上下文，用于控制预连接的超时和取消
+//
+// 返回:
+//   - error: 密钥解析失败时返回 ErrKeyParse 类错误，无法连接网关或 Ping 失败时返回对应错误，多个错误会被合并返回
+//
+// 注意:
+//   - Warmup 是可选的，不调用时密钥会在首次使用时解析
+//   - 预连接只关心连接是否建立，不检查网关返回的 HTTP 状态码
+//
+// 示例:
+//
+//	client, err := sdk.NewClient(config)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := client.Warmup(ctx); err != nil {
+//	    log.Printf("客户端预热失败: %v", err)
+//	}
+func (c *Client) Warmup(ctx context.Context) error {
+	ctx = contextOrBackground(ctx)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	run := func(task func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := task(); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	run(func() error {
+		// 使用外部签名器时私钥不在本进程中，无需解析
+		if c.config.Signer != nil {
+			return nil
+		}
+		if _, err := c.keys.getPrivateKey(); err != nil {
+			return fmt.Errorf("PrivateKey is invalid: %w", err)
+		}
+		return nil
+	})
+	run(func() error {
+		// 使用外部验证器时无需解析平台公钥
+		if c.config.Verifier != nil {
+			return nil
+		}
+		if _, err := c.keys.getPublicKey(); err != nil {
+			return fmt.Errorf("PlatFormPublicKey is invalid: %w", err)
+		}
+		return nil
+	})
+	run(func() error {
+		return c.preDial(ctx)
+	})
+
+	wg.Wait()
+	if len(errs) == 0 && c.config.WarmupPing {
+		return c.Ping(ctx)
+	}
+	return errors.Join(errs...)
+}
+
+// preDial 向 BaseURL 发送一个 HEAD 请求以建立连接
+// 请求直接通过底层 http.Client 发送，不经过签名和错误处理中间件，
+// 响应体读取完毕后连接会回到连接池中供后续请求复用
+func (c *Client) preDial(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.config.baseURL(), nil)
+	if err != nil {
+		return ErrInvalidConfig(fmt.Sprintf("BaseURL is invalid: %v", err))
+	}
+	req.Header.Set("User-Agent", c.config.userAgent())
+
+	resp, err := c.restyClient.GetClient().Do(req)
+	if err != nil {
+		return &SDKError{
+			Code:       ErrNetworkError.Code,
+			Message:    fmt.Sprintf("failed to connect to gateway: %v", err),
+			StatusCode: 0,
+		}
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Ping 检查网关连通性和商户凭证
+// 发送一次经过签名的只读查询(查询当天的第一条退款记录)，网关正常响应且业务状态码为成功时返回 nil，
+// 说明网关可达、商户编号有效且请求签名被网关接受
+//
+// 参数:
+//   - ctx: 上下文，用于控制超时和取消
+//
+// 返回:
+//   - error: 网关不可达或请求被拒绝时返回 SDKError
+//
+// 使用场景:
+//   - 服务就绪探针(readiness probe)
+//   - 部署后的冒烟测试
+//   - 可在 Warmup 之后调用，同时完成连接预热和凭证检查
+//
+// 示例:
+//
+//	if err := client.Ping(ctx); err != nil {
+//	    log.Fatalf("皓臻支付网关不可用: %v", err)
+//	}
+func (c *Client) Ping(ctx context.Context) error {
+	today := c.config.now().Format("20060102")
+	req := &QueryRefundListRequest{
+		StartDate: today,
+		EndDate:   today,
+		PageNum:   1,
+		PageSize:  1,
+	}
+
+	var data *RefundListResponse
+	return c.Payment.doRequest(ctx, OperationPing, c.config.endpointPath(OperationQueryRefundList), req, &data)
+}
+
+// Close 关闭客户端，释放连接池中的空闲连接
+// 按租户等维度动态创建客户端时，应在不再使用后调用，避免空闲连接占用文件描述符
+//
+// 返回:
+//   - error: 目前总是返回 nil，保留返回值以便后续释放其他资源
+//
+// 注意:
+//   - 调用后不应再使用该客户端及其 Payment 服务发起请求
+//   - 可重复调用，只有首次调用生效
+//
+// 示例:
+//
+//	client, err := sdk.NewClient(config)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer client.Close()
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.restyClient.GetClient().CloseIdleConnections()
+	})
+	return nil
+}
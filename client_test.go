@@ -0,0 +1,126 @@
+package haozpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// warmupGateway 记录 Warmup 发出的请求的测试网关
+type warmupGateway struct {
+	mu       sync.Mutex
+	requests []string
+	// code Ping 请求返回的业务码
+	code string
+}
+
+func (g *warmupGateway) handle(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	g.requests = append(g.requests, r.Method+" "+r.URL.Path)
+	g.mu.Unlock()
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"code":` + g.code + `,"message":"msg","data":{"total":0,"list":[]}}`))
+}
+
+func (g *warmupGateway) seen() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.requests...)
+}
+
+// TestWarmupPreDial Warmup 默认只发送预连接的 HEAD 请求
+func TestWarmupPreDial(t *testing.T) {
+	gw := &warmupGateway{code: "0"}
+	client := newTestClient(t, gw.handle)
+
+	if err := client.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	got := gw.seen()
+	if len(got) != 1 || got[0] != "HEAD /" {
+		t.Fatalf("requests = %v, want [HEAD /]", got)
+	}
+}
+
+// TestWarmupCachesKeys Warmup 完成后密钥缓存中应已有解析好的私钥和公钥
+func TestWarmupCachesKeys(t *testing.T) {
+	key, _, _ := testKeyPair(t)
+	client := newTestClient(t, okHandler)
+	if client.keys.privateKey != nil || client.keys.publicKey != nil {
+		t.Fatal("keys parsed before Warmup")
+	}
+
+	if err := client.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	if client.keys.privateKey == nil || !client.keys.privateKey.Equal(key) {
+		t.Fatal("private key not cached after Warmup")
+	}
+	if client.keys.publicKey == nil || !client.keys.publicKey.Equal(&key.PublicKey) {
+		t.Fatal("public key not cached after Warmup")
+	}
+}
+
+// TestWarmupKeyParseError 私钥或平台公钥无法解析时 Warmup 返回 ErrKeyParse 类错误
+func TestWarmupKeyParseError(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Config)
+	}{
+		{name: "private key", configure: func(cfg *Config) { cfg.WithPrivateKey("not a key") }},
+		{name: "public key", configure: func(cfg *Config) { cfg.WithPlatFormPublicKey("not a key") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, okHandler, tt.configure)
+			if err := client.Warmup(context.Background()); !errors.Is(err, ErrKeyParse) {
+				t.Fatalf("Warmup: err = %v, want ErrKeyParse", err)
+			}
+		})
+	}
+}
+
+// TestWarmupPing 开启 WarmupPing 后预连接成功再发送 Ping，Ping 失败时 Warmup 返回该错误
+func TestWarmupPing(t *testing.T) {
+	pingPath := OperationQueryRefundList.DefaultPath()
+
+	gw := &warmupGateway{code: "0"}
+	client := newTestClient(t, gw.handle, func(cfg *Config) { cfg.WithWarmupPing(true) })
+	if err := client.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	got := gw.seen()
+	if len(got) != 2 || got[0] != "HEAD /" || got[1] != "POST "+pingPath {
+		t.Fatalf("requests = %v, want [HEAD / POST %s]", got, pingPath)
+	}
+
+	gw = &warmupGateway{code: "4001"}
+	client = newTestClient(t, gw.handle, func(cfg *Config) { cfg.WithWarmupPing(true) })
+	err := client.Warmup(context.Background())
+	var sdkErr *SDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Operation != OperationPing {
+		t.Fatalf("Warmup with rejected ping: err = %v, want SDKError for Ping", err)
+	}
+}
+
+// TestWarmupSkipsPingOnFailure 密钥解析失败时不发送 Ping
+func TestWarmupSkipsPingOnFailure(t *testing.T) {
+	gw := &warmupGateway{code: "0"}
+	client := newTestClient(t, gw.handle, func(cfg *Config) {
+		cfg.WithWarmupPing(true).WithPrivateKey("not a key")
+	})
+
+	if err := client.Warmup(context.Background()); !errors.Is(err, ErrKeyParse) {
+		t.Fatalf("Warmup: err = %v, want ErrKeyParse", err)
+	}
+	for _, req := range gw.seen() {
+		if req != "HEAD /" {
+			t.Fatalf("unexpected request %q after key parse failure", req)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package haozpay
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 环境变量名，由 ConfigFromEnv 读取
+const (
+	// EnvBaseURL 网关地址，设置 EnvEnvironment 为 production 时可省略
+	EnvBaseURL = "HAOZPAY_BASE_URL"
+	// EnvEnvironment 网关环境，取值为 production 或 sandbox，可选
+	EnvEnvironment = "HAOZPAY_ENVIRONMENT"
+	// EnvMerchantNo 商户编号，必填
+	EnvMerchantNo = "HAOZPAY_MERCHANT_NO"
+	// EnvPrivateKey 商户私钥(PEM 或纯私钥字符串)，与 EnvPrivateKeyPath 二选一
+	EnvPrivateKey = "HAOZPAY_PRIVATE_KEY"
+	// EnvPrivateKeyPath 商户私钥文件路径，与 EnvPrivateKey 二选一
+	EnvPrivateKeyPath = "HAOZPAY_PRIVATE_KEY_PATH"
+	// EnvPublicKey 平台公钥(PEM 或纯公钥字符串)，与 EnvPublicKeyPath 二选一
+	EnvPublicKey = "HAOZPAY_PUBLIC_KEY"
+	// EnvPublicKeyPath 平台公钥文件路径，与 EnvPublicKey 二选一
+	EnvPublicKeyPath = "HAOZPAY_PUBLIC_KEY_PATH"
+	// EnvTimeout 请求超时时间，time.ParseDuration 格式，例如 10s，可选
+	EnvTimeout = "HAOZPAY_TIMEOUT"
+	// EnvDebug 是否开启调试模式，strconv.ParseBool 格式，可选
+	EnvDebug = "HAOZPAY_DEBUG"
+)
+
+// ConfigFromEnv 从环境变量创建配置
+// 在 DefaultConfig 的基础上读取 HAOZPAY_ 开头的环境变量，未设置的可选项保持默认值，读取后调用 Validate 校验
+//
+// 支持的环境变量:
+//   - HAOZPAY_BASE_URL: 网关地址
+//   - HAOZPAY_ENVIRONMENT: 网关环境(production、sandbox)，为 production 时可省略 HAOZPAY_BASE_URL
+//   - HAOZPAY_MERCHANT_NO: 商户编号，必填
+//   - HAOZPAY_PRIVATE_KEY 或 HAOZPAY_PRIVATE_KEY_PATH: 商户私钥内容或文件路径，必须且只能设置一个
+//   - HAOZPAY_PUBLIC_KEY 或 HAOZPAY_PUBLIC_KEY_PATH: 平台公钥内容或文件路径，必须且只能设置一个
+//   - HAOZPAY_TIMEOUT: 请求超时时间，例如 10s
+//   - HAOZPAY_DEBUG: 是否开启调试模式，例如 true
+//
+// 返回:
+//   - *Config: 配置，可继续链式调用其他 With 方法
+//   - error: 环境变量缺失或无效时返回 *ConfigError，Field 为环境变量名；多个错误会被合并返回
+//
+// 注意:
+//   - 值为空字符串的环境变量视为未设置
+//   - 私钥不要写入镜像或代码仓库，建议通过密钥管理服务挂载文件后使用 *_PATH 变量
+//
+// 示例:
+//
+//	config, err := sdk.ConfigFromEnv()
+//	if err != nil {
+//	    log.Fatalf("加载支付配置失败: %v", err)
+//	}
+//	client, err := sdk.NewClient(config)
+func ConfigFromEnv() (*Config, error) {
+	config := DefaultConfig()
+	var errs []error
+
+	config.BaseURL = envValue(EnvBaseURL)
+	if value := envValue(EnvEnvironment); value != "" {
+		switch strings.ToLower(value) {
+		case "production":
+			config.Environment = EnvironmentProduction
+		case "sandbox":
+			config.Environment = EnvironmentSandbox
+		default:
+			errs = append(errs, envError(EnvEnvironment, fmt.Sprintf("must be production or sandbox, got %q", value)))
+		}
+	}
+	if config.BaseURL == "" && config.Environment.BaseURL() == "" {
+		errs = append(errs, envError(EnvBaseURL, "is not set, set it or set "+EnvEnvironment+"=production"))
+	}
+
+	config.MerchantNo = envValue(EnvMerchantNo)
+	if config.MerchantNo == "" {
+		errs = append(errs, envError(EnvMerchantNo, "is not set"))
+	}
+
+	var err error
+	if config.PrivateKey, err = envKey(EnvPrivateKey, EnvPrivateKeyPath); err != nil {
+		errs = append(errs, err)
+	}
+	if config.PlatFormPublicKey, err = envKey(EnvPublicKey, EnvPublicKeyPath); err != nil {
+		errs = append(errs, err)
+	}
+
+	if value := envValue(EnvTimeout); value != "" {
+		if timeout, err := time.ParseDuration(value); err != nil || timeout <= 0 {
+			errs = append(errs, envError(EnvTimeout, fmt.Sprintf("must be a positive duration such as 10s, got %q", value)))
+		} else {
+			config.Timeout = timeout
+		}
+	}
+	if value := envValue(EnvDebug); value != "" {
+		if debug, err := strconv.ParseBool(value); err != nil {
+			errs = append(errs, envError(EnvDebug, fmt.Sprintf("must be true or false, got %q", value)))
+		} else {
+			config.Debug = debug
+		}
+	}
+
+	// 环境变量缺失时不再重复报告 Validate 中对应字段的错误
+	if len(errs) == 0 {
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, errors.Join(errs...)
+}
+
+// envValue 读取环境变量，去除首尾空白
+func envValue(name string) string {
+	return strings.TrimSpace(os.Getenv(name))
+}
+
+// envKey 读取密钥内容，内容和文件路径两个环境变量必须且只能设置一个
+func envKey(name, pathName string) (string, error) {
+	value, path := os.Getenv(name), envValue(pathName)
+	switch {
+	case strings.TrimSpace(value) != "" && path != "":
+		return "", envError(name, "cannot be set together with "+pathName)
+	case strings.TrimSpace(value) != "":
+		return value, nil
+	case path != "":
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", envError(pathName, fmt.Sprintf("failed to read key file: %v", err))
+		}
+		return string(b), nil
+	}
+	return "", envError(name, "is not set (or set "+pathName+" to a key file)")
+}
+
+// envError 环境变量缺失或无效时返回的错误
+func envError(name, message string) *ConfigError {
+	return &ConfigError{Field: name, Message: "environment variable " + message}
+}
@@ -0,0 +1,50 @@
+package haozpay
+
+import (
+	"context"
+	"net/http"
+)
+
+// DryRunError 试运行结果
+// 开启试运行后，请求在完成签名后不会发送，支付接口返回该错误，其中包含将要发送的完整请求内容，
+// 可用于与服务端逐字节比对签名
+//
+// 示例:
+//
+//	_, err := client.Payment.CreateOrder(ctx, req, sdk.WithDryRun())
+//	var dryRun *sdk.DryRunError
+//	if errors.As(err, &dryRun) {
+//	    fmt.Println(string(dryRun.Body))
+//	}
+type DryRunError struct {
+	// Method HTTP 请求方法
+	Method string
+	// URL 请求地址
+	URL string
+	// Header 请求头
+	Header http.Header
+	// Request 签名后的请求
+	Request *HaozPayRequest
+	// Body 序列化后的请求体，与实际发送的内容一致
+	Body []byte
+	// SignString 参与签名的原始字符串，即 SHA256 摘要前的 key=value&... 字符串
+	SignString string
+}
+
+func (e *DryRunError) Error() string {
+	return "dry run: request signed but not sent"
+}
+
+// dryRunContextKey 试运行标记在上下文中的键
+type dryRunContextKey struct{}
+
+// contextWithDryRun 返回带有试运行标记的上下文
+func contextWithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunContextKey{}, true)
+}
+
+// isDryRun 判断上下文是否带有试运行标记
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunContextKey{}).(bool)
+	return dryRun
+}
@@ -0,0 +1,44 @@
+package haozpay
+
+// Environment 网关环境
+// 通过 Config.Environment 选择环境时自动使用对应的网关地址，避免手动填写 BaseURL 出错
+type Environment int
+
+const (
+	// EnvironmentUnspecified 未指定环境，需要通过 BaseURL 设置网关地址
+	EnvironmentUnspecified Environment = iota
+	// EnvironmentProduction 生产环境
+	EnvironmentProduction
+	// EnvironmentSandbox 沙箱环境
+	// 沙箱网关地址由平台在商户入驻时提供，需要同时通过 BaseURL 设置
+	EnvironmentSandbox
+)
+
+// ProductionBaseURL 生产环境网关地址
+const ProductionBaseURL = "https://gate.haozpay.com"
+
+// environmentBaseURLs 各环境的默认网关地址
+var environmentBaseURLs = map[Environment]string{
+	EnvironmentProduction: ProductionBaseURL,
+}
+
+// environmentNames 环境名称
+var environmentNames = map[Environment]string{
+	EnvironmentUnspecified: "Unspecified",
+	EnvironmentProduction:  "Production",
+	EnvironmentSandbox:     "Sandbox",
+}
+
+// String 返回环境名称
+func (e Environment) String() string {
+	if name, ok := environmentNames[e]; ok {
+		return name
+	}
+	return environmentNames[EnvironmentUnspecified]
+}
+
+// BaseURL 返回环境的默认网关地址
+// 没有默认地址的环境返回空字符串
+func (e Environment) BaseURL() string {
+	return environmentBaseURLs[e]
+}
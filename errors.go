@@ -1,20 +1,87 @@
 package haozpay
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
 
 type SDKError struct {
 	Code       int
 	Message    string
 	RequestID  string
 	StatusCode int
+	Operation  Operation
+	TraceID    string
+	// Details 网关返回的错误详情原文(JSON)，例如字段级的校验错误，网关未返回时为空
+	Details    json.RawMessage
+	retryAfter time.Duration
+	cause      error
 }
 
 func (e *SDKError) Error() string {
+	msg := fmt.Sprintf("[%d] %s (", e.Code, e.Message)
 	if e.RequestID != "" {
-		return fmt.Sprintf("[%d] %s (RequestID: %s, StatusCode: %d)", 
-			e.Code, e.Message, e.RequestID, e.StatusCode)
+		msg += fmt.Sprintf("RequestID: %s, ", e.RequestID)
+	}
+	if e.TraceID != "" {
+		msg += fmt.Sprintf("TraceID: %s, ", e.TraceID)
+	}
+	msg += fmt.Sprintf("StatusCode: %d)", e.StatusCode)
+	if e.Operation != OperationUnknown {
+		return e.Operation.String() + ": " + msg
+	}
+	return msg
+}
+
+func (e *SDKError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+func (e *SDKError) Unwrap() error {
+	return e.cause
+}
+
+// HTTPStatus 返回适合对外暴露的 HTTP 状态码
+// 重新通过 HTTP 提供支付能力的服务可以直接使用，不必在各处手写错误码映射
+//
+// 返回:
+//   - int: 网关返回了错误状态码(>= 400)时原样返回；否则按错误码映射：
+//     ErrInvalidRequest 为 400，ErrUnauthorized 为 401，ErrForbidden 为 403，ErrNotFound 为 404，
+//     ErrRateLimited 为 429，ErrTimeout 为 504，网络错误和无效、验签失败、超限、缺少 data 的响应为 502，
+//     其他错误(包括网关返回的业务错误码)为 500
+//
+// 示例:
+//
+//	var sdkErr *sdk.SDKError
+//	if errors.As(err, &sdkErr) {
+//	    http.Error(w, sdkErr.Message, sdkErr.HTTPStatus())
+//	    return
+//	}
+func (e *SDKError) HTTPStatus() int {
+	if e.StatusCode >= http.StatusBadRequest {
+		return e.StatusCode
+	}
+	switch e.Code {
+	case ErrInvalidRequest.Code:
+		return http.StatusBadRequest
+	case ErrUnauthorized.Code:
+		return http.StatusUnauthorized
+	case ErrForbidden.Code:
+		return http.StatusForbidden
+	case ErrNotFound.Code:
+		return http.StatusNotFound
+	case ErrRateLimited.Code:
+		return http.StatusTooManyRequests
+	case ErrTimeout.Code:
+		return http.StatusGatewayTimeout
+	case ErrNetworkError.Code, ErrInvalidResponse.Code, ErrInvalidSign.Code, ErrResponseTooLarge.Code, ErrEmptyData.Code:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
 	}
-	return fmt.Sprintf("[%d] %s (StatusCode: %d)", e.Code, e.Message, e.StatusCode)
 }
 
 func NewSDKError(code int, message string, statusCode int) *SDKError {
@@ -50,13 +117,54 @@ func ErrInvalidConfig(message string) *ConfigError {
 	}
 }
 
+type BatchValidationError struct {
+	Indices  []int
+	Messages []string
+}
+
+func (e *BatchValidationError) Error() string {
+	parts := make([]string, len(e.Indices))
+	for i, index := range e.Indices {
+		parts[i] = fmt.Sprintf("item %d: %s", index, e.Messages[i])
+	}
+	return fmt.Sprintf("batch validation failed: %s", strings.Join(parts, "; "))
+}
+
+// AmountMismatchError 订单金额与预期金额不一致
+type AmountMismatchError struct {
+	MerchantOrderNo string
+	Expected        float64
+	Actual          float64
+}
+
+func (e *AmountMismatchError) Error() string {
+	return fmt.Sprintf("order %s amount mismatch: expected %.2f, got %.2f", e.MerchantOrderNo, e.Expected, e.Actual)
+}
+
+// RefundExceedsError 退款金额超过订单剩余可退金额
+// 开启 Config.RefundPrecheck 后由 CreateRefund 在发送请求前返回
+type RefundExceedsError struct {
+	OrderNo      string
+	RefundAmount float64
+	Refundable   float64
+}
+
+func (e *RefundExceedsError) Error() string {
+	return fmt.Sprintf("order %s refund amount %.2f exceeds refundable amount %.2f", e.OrderNo, e.RefundAmount, e.Refundable)
+}
+
 var (
-	ErrTimeout         = NewSDKError(1001, "request timeout", 0)
-	ErrNetworkError    = NewSDKError(1002, "network error", 0)
-	ErrInvalidResponse = NewSDKError(1003, "invalid response", 0)
-	ErrInvalidRequest  = NewSDKError(1004, "invalid request", 0)
-	ErrUnauthorized    = NewSDKError(1005, "unauthorized", 401)
-	ErrForbidden       = NewSDKError(1006, "forbidden", 403)
-	ErrNotFound        = NewSDKError(1007, "not found", 404)
-	ErrServerError     = NewSDKError(1008, "server error", 500)
-)
\ No newline at end of file
+	ErrTimeout          = NewSDKError(1001, "request timeout", 0)
+	ErrNetworkError     = NewSDKError(1002, "network error", 0)
+	ErrInvalidResponse  = NewSDKError(1003, "invalid response", 0)
+	ErrInvalidRequest   = NewSDKError(1004, "invalid request", 0)
+	ErrUnauthorized     = NewSDKError(1005, "unauthorized", 401)
+	ErrForbidden        = NewSDKError(1006, "forbidden", 403)
+	ErrNotFound         = NewSDKError(1007, "not found", 404)
+	ErrServerError      = NewSDKError(1008, "server error", 500)
+	ErrInvalidSign      = NewSDKError(1009, "invalid signature", 0)
+	ErrRateLimited      = NewSDKError(1010, "rate limit exceeded", 0)
+	ErrResponseTooLarge = NewSDKError(1011, "response too large", 0)
+	// ErrEmptyData 网关返回成功(code 为 0)但缺少 data，下单、查询等必须返回数据的接口默认返回该错误
+	ErrEmptyData = NewSDKError(1012, "empty data on success", 0)
+)
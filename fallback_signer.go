@@ -0,0 +1,43 @@
+package haozpay
+
+import (
+	"context"
+	"errors"
+)
+
+// fallbackSignContextKey 使用备用签名器的标记在上下文中的键
+type fallbackSignContextKey struct{}
+
+// contextWithFallbackSign 返回带有使用备用签名器标记的上下文
+func contextWithFallbackSign(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fallbackSignContextKey{}, true)
+}
+
+// isFallbackSign 判断上下文是否带有使用备用签名器的标记
+func isFallbackSign(ctx context.Context) bool {
+	fallback, _ := ctx.Value(fallbackSignContextKey{}).(bool)
+	return fallback
+}
+
+// selectSigner 按请求上下文选择签名器，带有备用签名器标记且配置了备用签名器时使用备用签名器
+func selectSigner(ctx context.Context, signer, fallback Signer) Signer {
+	if fallback != nil && isFallbackSign(ctx) {
+		return fallback
+	}
+	return signer
+}
+
+// isSignatureRejected 判断错误是否为网关拒绝签名
+// 网关没有统一的签名错误码，以 Config.FallbackSignCodes 中配置的业务码为准
+func (c *Config) isSignatureRejected(err error) bool {
+	var sdkErr *SDKError
+	if !errors.As(err, &sdkErr) {
+		return false
+	}
+	for _, code := range c.FallbackSignCodes {
+		if sdkErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package haozpay
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// formContentType 表单请求的 Content-Type
+const formContentType = "application/x-www-form-urlencoded"
+
+// formEncodingContextKey 表单编码标记在上下文中的键
+type formEncodingContextKey struct{}
+
+// contextWithFormEncoding 返回带有表单编码标记的上下文
+func contextWithFormEncoding(ctx context.Context) context.Context {
+	return context.WithValue(ctx, formEncodingContextKey{}, true)
+}
+
+// isFormEncoding 判断上下文是否带有表单编码标记
+func isFormEncoding(ctx context.Context) bool {
+	form, _ := ctx.Value(formEncodingContextKey{}).(bool)
+	return form
+}
+
+// encodeFormBody 将签名后的请求编码为表单
+// 表单字段即参与签名的参数(bizBody 展开后的字段以及 merchantNo、timestamp)，
+// 按参数名升序排列，取值规则与 BuildSignString 相同，sign 字段附加在最后
+func encodeFormBody(haozReq *HaozPayRequest) (string, error) {
+	params, err := buildSignParams(haozReq)
+	if err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		value := params[key]
+		if key == "sign" || value == nil {
+			continue
+		}
+		valueStr := formatSignValue(value)
+		if strings.TrimSpace(valueStr) == "" {
+			continue
+		}
+		parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(valueStr))
+	}
+	// 签名写入请求头时请求中没有 sign 字段
+	if haozReq.Sign != "" {
+		parts = append(parts, "sign="+url.QueryEscape(haozReq.Sign))
+	}
+	return strings.Join(parts, "&"), nil
+}
+
+// formEncodingMiddleware 表单编码中间件
+// 必须注册在签名中间件之后，请求上下文带有表单编码标记时，将签名后的请求改为表单发送
+//
+// 返回:
+//   - resty.RequestMiddleware: resty 请求中间件函数
+func formEncodingMiddleware() resty.RequestMiddleware {
+	return func(c *resty.Client, r *resty.Request) error {
+		if !isFormEncoding(r.Context()) {
+			return nil
+		}
+		haozReq, ok := r.Body.(*HaozPayRequest)
+		if !ok {
+			return nil
+		}
+
+		body, err := encodeFormBody(haozReq)
+		if err != nil {
+			return fmt.Errorf("failed to encode form body: %w", err)
+		}
+		r.SetHeader("Content-Type", formContentType)
+		r.SetBody(body)
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+package haozpay
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// 密钥和签名相关错误的类别，可通过 errors.Is 判断，与错误信息的语言无关
+var (
+	// ErrKeyParse 密钥解析失败
+	ErrKeyParse = errors.New("key parse error")
+	// ErrSigning 签名运算失败
+	ErrSigning = errors.New("signing error")
+)
+
+// Language 错误信息语言
+type Language int32
+
+const (
+	// LanguageChinese 中文，默认值
+	LanguageChinese Language = iota
+	// LanguageEnglish 英文
+	LanguageEnglish
+)
+
+// errorLanguage 当前错误信息语言
+var errorLanguage atomic.Int32
+
+// SetErrorLanguage 设置密钥解析、签名等错误信息的语言
+// 只影响错误信息文本，errors.Is 判断不受影响；可在程序启动时调用一次
+//
+// 参数:
+//   - lang: 错误信息语言，默认 LanguageChinese
+//
+// 示例:
+//
+//	sdk.SetErrorLanguage(sdk.LanguageEnglish)
+func SetErrorLanguage(lang Language) {
+	errorLanguage.Store(int32(lang))
+}
+
+// message 错误信息的多语言文本
+type message struct {
+	zh string
+	en string
+}
+
+// text 返回当前语言的文本
+func (m message) text() string {
+	if Language(errorLanguage.Load()) == LanguageEnglish {
+		return m.en
+	}
+	return m.zh
+}
+
+// 错误信息文本
+var (
+	msgParsePrivateKey  = message{zh: "解析私钥失败", en: "failed to parse private key"}
+	msgParsePublicKey   = message{zh: "解析公钥失败", en: "failed to parse public key"}
+	msgSigning          = message{zh: "RSA私钥加密失败", en: "RSA private key operation failed"}
+	msgRandom           = message{zh: "生成随机数失败", en: "failed to generate random nonce"}
+	msgDataTooLong      = message{zh: "数据过长，超过RSA限制", en: "data too long for RSA key size"}
+	msgKeyTooShort      = message{zh: "RSA密钥长度过短", en: "RSA key too short"}
+	msgKeyTooSmall      = message{zh: "RSA密钥长度为%d位，低于要求的%d位", en: "RSA key is %d bits, below the required %d bits"}
+	msgSegment          = message{zh: "第%d段加密失败", en: "failed to encrypt segment %d"}
+	msgPrivateKeyPEM    = message{zh: "私钥PEM格式解析失败", en: "invalid private key PEM"}
+	msgUnsupportedKey   = message{zh: "不支持的私钥格式", en: "unsupported private key format"}
+	msgNotRSAPrivateKey = message{zh: "不是RSA私钥", en: "not an RSA private key"}
+	msgPublicKeyFormat  = message{zh: "公钥不是有效的PEM或Base64格式", en: "public key is not valid PEM or Base64"}
+	msgInvalidPublicKey = message{zh: "公钥格式无效", en: "invalid public key"}
+	msgNotRSAPublicKey  = message{zh: "不是RSA公钥", en: "not an RSA public key"}
+)
+
+// localizedError 带有错误类别的本地化错误
+// Error 按当前语言输出，Is 匹配错误类别，Unwrap 返回原始错误
+type localizedError struct {
+	kind  error
+	msg   message
+	args  []interface{}
+	cause error
+}
+
+// newLocalizedError 创建本地化错误
+//
+// 参数:
+//   - kind: 错误类别，例如 ErrKeyParse，可为 nil
+//   - msg: 错误信息文本
+//   - cause: 原始错误，可为 nil
+//   - args: 格式化参数
+func newLocalizedError(kind error, msg message, cause error, args ...interface{}) error {
+	return &localizedError{kind: kind, msg: msg, args: args, cause: cause}
+}
+
+func (e *localizedError) Error() string {
+	text := e.msg.text()
+	if len(e.args) > 0 {
+		text = fmt.Sprintf(text, e.args...)
+	}
+	if e.cause != nil {
+		return text + ": " + e.cause.Error()
+	}
+	return text
+}
+
+func (e *localizedError) Is(target error) bool {
+	return e.kind != nil && target == e.kind
+}
+
+func (e *localizedError) Unwrap() error {
+	return e.cause
+}
@@ -0,0 +1,138 @@
+package haozpay
+
+import (
+	"crypto/rsa"
+	"sync"
+)
+
+// DefaultMinKeyBits 默认要求的最小 RSA 密钥长度(位)
+const DefaultMinKeyBits = 2048
+
+// KeyOption 包级签名、验签函数的密钥选项
+// 用于 GenerateSign、SignNotification、VerifyKeyPair、VerifyReturnURL、NewPEMSigner 和 NewPEMVerifier，
+// 客户端使用的密钥通过 Config.WithMinKeyBits 设置
+type KeyOption func(*keyOptions)
+
+// keyOptions 包级函数的密钥选项
+type keyOptions struct {
+	// minBits 要求的最小模长(位)
+	minBits int
+}
+
+// WithMinKeyBits 设置包级函数解析密钥时要求的最小模长
+//
+// 参数:
+//   - bits: 最小模长(位)，0 表示使用 DefaultMinKeyBits(2048)
+//
+// 注意:
+//   - 与 Config.WithMinKeyBits 相同，仅用于仍在使用 1024 位旧密钥的商户过渡
+//
+// 示例:
+//
+//	sign, err := sdk.GenerateSign(params, legacyPrivateKeyPEM, sdk.WithMinKeyBits(1024))
+func WithMinKeyBits(bits int) KeyOption {
+	return func(o *keyOptions) {
+		o.minBits = bits
+	}
+}
+
+// minKeyBitsOf 返回密钥选项要求的最小模长，未设置时使用默认值
+func minKeyBitsOf(opts []KeyOption) int {
+	o := keyOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.minBits > 0 {
+		return o.minBits
+	}
+	return DefaultMinKeyBits
+}
+
+// keyCache RSA 密钥缓存
+// 解析 PEM 密钥需要 Base64 解码和 ASN.1 解析，开销较大，
+// 缓存解析结果后，每次请求签名和回调验签都无需重复解析
+//
+// 私钥和公钥分别加锁，互不阻塞，便于 Warmup 并发预解析
+type keyCache struct {
+	// privateKeyPEM 商户私钥原文
+	privateKeyPEM string
+	// publicKeyPEM 平台公钥原文
+	publicKeyPEM string
+	// minBits 私钥和公钥要求的最小模长(位)
+	minBits int
+
+	privateMu  sync.Mutex
+	privateKey *rsa.PrivateKey
+
+	publicMu  sync.Mutex
+	publicKey *rsa.PublicKey
+}
+
+// newKeyCache 创建密钥缓存，密钥在首次使用时才会被解析，模长小于 minBits 的密钥解析失败
+func newKeyCache(privateKeyPEM, publicKeyPEM string, minBits int) *keyCache {
+	return &keyCache{
+		privateKeyPEM: privateKeyPEM,
+		publicKeyPEM:  publicKeyPEM,
+		minBits:       minBits,
+	}
+}
+
+// getPrivateKey 获取解析后的商户私钥，首次调用时解析并缓存
+func (k *keyCache) getPrivateKey() (*rsa.PrivateKey, error) {
+	k.privateMu.Lock()
+	defer k.privateMu.Unlock()
+
+	if k.privateKey != nil {
+		return k.privateKey, nil
+	}
+
+	privateKey, err := parsePrivateKey(k.privateKeyPEM, k.minBits)
+	if err != nil {
+		return nil, err
+	}
+	k.privateKey = privateKey
+	return privateKey, nil
+}
+
+// setPrivateKey 解析并替换商户私钥
+// 解析在加锁前完成，解析失败时保留原私钥；替换后的签名使用新私钥，
+// 已经取得私钥的签名仍使用原私钥完成，单个请求不会混用两个私钥
+func (k *keyCache) setPrivateKey(privateKeyPEM string) error {
+	privateKey, err := parsePrivateKey(privateKeyPEM, k.minBits)
+	if err != nil {
+		return err
+	}
+
+	k.privateMu.Lock()
+	defer k.privateMu.Unlock()
+
+	k.privateKeyPEM = privateKeyPEM
+	k.privateKey = privateKey
+	return nil
+}
+
+// getPublicKey 获取解析后的平台公钥，首次调用时解析并缓存
+func (k *keyCache) getPublicKey() (*rsa.PublicKey, error) {
+	k.publicMu.Lock()
+	defer k.publicMu.Unlock()
+
+	if k.publicKey != nil {
+		return k.publicKey, nil
+	}
+
+	publicKey, err := parsePublicKey(k.publicKeyPEM, k.minBits)
+	if err != nil {
+		return nil, err
+	}
+	k.publicKey = publicKey
+	return publicKey, nil
+}
+
+// checkKeyBits 检查 RSA 密钥模长是否满足最小长度要求
+// 配置错误的短密钥(如 1024 位)会在不知不觉中削弱签名和验签的安全性，解析时即拒绝
+func checkKeyBits(key *rsa.PublicKey, minBits int) error {
+	if bits := key.N.BitLen(); bits < minBits {
+		return newLocalizedError(ErrKeyParse, msgKeyTooSmall, nil, bits, minBits)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package haozpay
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencyStats 单个接口路径的响应耗时统计
+// 耗时取自底层 HTTP 请求(resty 的 Response.Time)，即最后一次尝试从发送请求到读取完响应的时间，
+// 流式查询为收到响应头的时间；不包含签名、限流等待和重试间隔，命中缓存和未收到响应的请求不计入
+type LatencyStats struct {
+	// Count 统计的请求数
+	Count int64
+	// Mean 平均耗时
+	Mean time.Duration
+	// P50 中位数耗时
+	P50 time.Duration
+	// P95 95 分位耗时
+	P95 time.Duration
+	// P99 99 分位耗时
+	P99 time.Duration
+	// Max 最大耗时
+	Max time.Duration
+}
+
+const (
+	// latencyBucketBase 第一个桶的上界，小于该值的耗时都计入第一个桶
+	latencyBucketBase = 100 * time.Microsecond
+	// latencyBucketGrowth 相邻桶上界的比例，分位数的相对误差不超过 10%
+	latencyBucketGrowth = 1.1
+	// latencyBucketCount 桶的数量，最后一个桶的上界约为 3 分钟，更长的耗时计入最后一个桶
+	latencyBucketCount = 152
+)
+
+// latencyBucketBounds 各桶的上界，按指数增长
+var latencyBucketBounds = func() [latencyBucketCount]time.Duration {
+	var bounds [latencyBucketCount]time.Duration
+	for i := range bounds {
+		bounds[i] = time.Duration(float64(latencyBucketBase) * math.Pow(latencyBucketGrowth, float64(i)))
+	}
+	return bounds
+}()
+
+// latencyHistogram 指数分桶的耗时直方图
+// 内存占用固定，与请求数无关，分位数取所在桶的上界(不超过最大耗时)
+type latencyHistogram struct {
+	count   int64
+	sum     time.Duration
+	max     time.Duration
+	buckets [latencyBucketCount]int64
+}
+
+func (h *latencyHistogram) record(d time.Duration) {
+	i := sort.Search(latencyBucketCount, func(i int) bool { return d <= latencyBucketBounds[i] })
+	if i == latencyBucketCount {
+		i = latencyBucketCount - 1
+	}
+	h.buckets[i]++
+	h.count++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// quantile 返回分位数 q(0 < q <= 1)对应的耗时
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+	rank := int64(math.Ceil(q * float64(h.count)))
+	var seen int64
+	for i, n := range h.buckets {
+		seen += n
+		if seen >= rank {
+			if latencyBucketBounds[i] > h.max {
+				return h.max
+			}
+			return latencyBucketBounds[i]
+		}
+	}
+	return h.max
+}
+
+func (h *latencyHistogram) stats() LatencyStats {
+	if h.count == 0 {
+		return LatencyStats{}
+	}
+	return LatencyStats{
+		Count: h.count,
+		Mean:  h.sum / time.Duration(h.count),
+		P50:   h.quantile(0.50),
+		P95:   h.quantile(0.95),
+		P99:   h.quantile(0.99),
+		Max:   h.max,
+	}
+}
+
+// latencyRecorder 按接口路径统计响应耗时
+type latencyRecorder struct {
+	mu    sync.Mutex
+	paths map[string]*latencyHistogram
+}
+
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{paths: make(map[string]*latencyHistogram)}
+}
+
+func (r *latencyRecorder) record(path string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.paths[path]
+	if !ok {
+		h = &latencyHistogram{}
+		r.paths[path] = h
+	}
+	h.record(d)
+}
+
+func (r *latencyRecorder) snapshot() map[string]LatencyStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make(map[string]LatencyStats, len(r.paths))
+	for path, h := range r.paths {
+		stats[path] = h.stats()
+	}
+	return stats
+}
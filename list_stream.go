@@ -0,0 +1,179 @@
+package haozpay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxErrorBodySize 流式解析时读取错误响应体的最大长度
+const maxErrorBodySize = 64 << 10
+
+// errStreamStopped 回调函数返回错误，停止解析
+var errStreamStopped = errors.New("stream stopped by callback")
+
+// responseStreamer 流式解析响应 data 字段
+// doRequest 的 data 参数实现该接口时，响应体不会整体读入内存，而是边读取边解析
+type responseStreamer interface {
+	// streamData 解析 data 字段的值，调用时 dec 位于该值之前
+	streamData(dec *json.Decoder) error
+}
+
+// QueryRefundListStream 流式查询退款列表
+// 与 QueryRefundList 参数相同，但不会一次性读入整个响应，而是逐条解析退款记录并调用 fn，
+// 内存占用与单条记录的大小相关，而与列表长度无关，适合大日期范围的批量任务
+//
+// 参数:
+//   - ctx: 上下文
+//   - req: 查询参数，StartDate 和 EndDate 必填
+//   - fn: 每条退款记录的回调函数，返回错误时停止解析并原样返回该错误
+//   - opts: 单次请求选项
+//
+// 返回:
+//   - *RefundListResponse: 分页信息，List 字段始终为空
+//   - error: 请求失败、响应解析失败或 fn 返回的错误
+//
+// 注意:
+//   - 网关在 data 之后才返回错误码时，fn 可能已经被调用
+//   - 开启 VerifyResponses 时需要完整的响应体才能验签，会先读取整个响应再逐条调用 fn
+//   - 流式响应不经过 SDK 的响应日志中间件，调试模式下不会打印响应体
+//
+// 示例:
+//
+//	page, err := client.Payment.QueryRefundListStream(ctx, req, func(refund *sdk.QueryRefundResponse) error {
+//	    return writer.Write(refund)
+//	})
+func (s *PaymentService) QueryRefundListStream(ctx context.Context, req *QueryRefundListRequest, fn func(*QueryRefundResponse) error, opts ...RequestOption) (*RefundListResponse, error) {
+	if s.config.VerifyResponses {
+		page, err := s.QueryRefundList(ctx, req, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for _, refund := range page.List {
+			if err := fn(refund); err != nil {
+				return nil, err
+			}
+		}
+		page.List = nil
+		return page, nil
+	}
+
+	streamer := &refundListStreamer{fn: fn}
+	err := s.doRequest(ctx, OperationQueryRefundList, s.config.endpointPath(OperationQueryRefundList), req, streamer, opts...)
+	if streamer.callbackErr != nil {
+		return nil, streamer.callbackErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &streamer.page, nil
+}
+
+// refundListStreamer 逐条解析退款列表
+type refundListStreamer struct {
+	fn          func(*QueryRefundResponse) error
+	page        RefundListResponse
+	callbackErr error
+}
+
+func (st *refundListStreamer) streamData(dec *json.Decoder) error {
+	return decodeObjectStream(dec, &st.page, map[string]func() error{
+		"list": func() error {
+			return decodeArrayStream(dec, func() error {
+				var refund QueryRefundResponse
+				if err := dec.Decode(&refund); err != nil {
+					return err
+				}
+				if err := st.fn(&refund); err != nil {
+					st.callbackErr = err
+					return errStreamStopped
+				}
+				return nil
+			})
+		},
+	})
+}
+
+// decodeResponseStream 流式解析网关响应
+// data 字段交给 streamer 解析，其余字段解析到返回的 Response 中
+func decodeResponseStream(r io.Reader, streamer responseStreamer) (Response, error) {
+	dec := json.NewDecoder(r)
+
+	var result Response
+	err := decodeObjectStream(dec, &result, map[string]func() error{
+		"data": func() error {
+			return streamer.streamData(dec)
+		},
+	})
+	return result, err
+}
+
+// decodeObjectStream 逐个字段解析 JSON 对象
+// handlers 中的字段由对应函数解析，其余字段收集后解析到 v 中，值为 null 时什么也不做
+func decodeObjectStream(dec *json.Decoder, v interface{}, handlers map[string]func() error) (err error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("expected JSON object, got %v", tok)
+	}
+
+	// 非流式字段体积很小，收集后借助标准库按结构体标签解析
+	// 解析中途出错时同样填充已读取的字段，便于错误信息携带请求ID等内容
+	fields := make(map[string]json.RawMessage)
+	defer func() {
+		if b, marshalErr := json.Marshal(fields); marshalErr == nil {
+			if unmarshalErr := json.Unmarshal(b, v); err == nil {
+				err = unmarshalErr
+			}
+		}
+	}()
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if handler, ok := handlers[key]; ok {
+			if err := handler(); err != nil {
+				return err
+			}
+			continue
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		fields[key] = raw
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// decodeArrayStream 逐个元素解析 JSON 数组，每个元素调用一次 decodeItem，值为 null 时什么也不做
+func decodeArrayStream(dec *json.Decoder, decodeItem func() error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected JSON array, got %v", tok)
+	}
+	for dec.More() {
+		if err := decodeItem(); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
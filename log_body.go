@@ -0,0 +1,58 @@
+package haozpay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// DefaultDebugBodyLimit 调试日志中请求体和响应体默认的最大打印字节数
+const DefaultDebugBodyLimit = 4096
+
+// formatLogBody 将请求体或响应体转换为适合打印到调试日志的文本
+//
+// 处理逻辑:
+//  1. gzip 压缩的内容(以 gzip 魔数开头)先解压，解压失败时只打印大小
+//  2. 不是合法 UTF-8 文本的内容(如其他压缩格式或二进制数据)只打印大小
+//  3. 超过 limit 字节时截断，并注明原始大小；limit 小于等于 0 时不截断
+func formatLogBody(body []byte, limit int) string {
+	if len(body) >= 2 && body[0] == 0x1f && body[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return fmt.Sprintf("[gzip body, %d bytes]", len(body))
+		}
+		// 只解压需要打印的部分，避免超大响应在日志中完整解压
+		reader := io.Reader(zr)
+		if limit > 0 {
+			reader = io.LimitReader(zr, int64(limit)+1)
+		}
+		plain, err := io.ReadAll(reader)
+		if err != nil && len(plain) == 0 {
+			return fmt.Sprintf("[gzip body, %d bytes]", len(body))
+		}
+		return "(gunzipped) " + truncateLogBody(plain, limit, fmt.Sprintf("%d compressed bytes", len(body)))
+	}
+	return truncateLogBody(body, limit, fmt.Sprintf("%d bytes", len(body)))
+}
+
+// truncateLogBody 按 limit 截断文本，截断位置对齐到完整字符，size 为截断时注明的原始大小
+func truncateLogBody(body []byte, limit int, size string) string {
+	truncated := limit > 0 && len(body) > limit
+	if truncated {
+		// 截断位置落在多字节字符中间时前移到该字符的开头
+		cut := limit
+		for i := 1; i < utf8.UTFMax && cut > 0 && !utf8.RuneStart(body[cut]); i++ {
+			cut--
+		}
+		body = body[:cut]
+	}
+	if !utf8.Valid(body) {
+		return fmt.Sprintf("[binary body, %s]", size)
+	}
+	if truncated {
+		return fmt.Sprintf("%s... (truncated, %s)", body, size)
+	}
+	return string(body)
+}
@@ -0,0 +1,56 @@
+package haozpay
+
+import (
+	"context"
+	"strings"
+)
+
+// merchantNoContextKey 覆盖商户编号在上下文中的键
+type merchantNoContextKey struct{}
+
+// ContextWithMerchantNo 返回携带商户编号的上下文
+// 使用该上下文调用支付接口时，请求以该商户编号签名和发送，覆盖 Config.MerchantNo，
+// 供服务商代多个子商户调用接口，不必为每个子商户创建客户端
+//
+// 参数:
+//   - ctx: 父上下文
+//   - merchantNo: 子商户编号，为空时使用 Config.MerchantNo
+//
+// 返回:
+//   - context.Context: 携带商户编号的上下文
+//
+// 注意:
+//   - 请求仍使用客户端配置的私钥或 Signer 签名，只适用于平台允许共用签名密钥的子商户
+//   - 商户编号决定请求以哪个商户的身份执行，不要直接使用来自终端用户的输入，
+//     应在业务系统中校验调用方有权操作该商户后再设置
+//   - 同时使用 WithMerchantNo 请求选项时以请求选项为准
+//
+// 示例:
+//
+//	ctx = sdk.ContextWithMerchantNo(ctx, subMerchantNo)
+//	order, err := client.Payment.CreateOrder(ctx, req)
+func ContextWithMerchantNo(ctx context.Context, merchantNo string) context.Context {
+	return context.WithValue(ctx, merchantNoContextKey{}, merchantNo)
+}
+
+// MerchantNoFromContext 获取 ContextWithMerchantNo 设置的商户编号
+// 未设置时返回空字符串
+func MerchantNoFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	merchantNo, _ := ctx.Value(merchantNoContextKey{}).(string)
+	return merchantNo
+}
+
+// merchantNo 返回本次请求使用的商户编号
+// 优先级依次为 WithMerchantNo 请求选项、ContextWithMerchantNo、Config.MerchantNo
+func (s *PaymentService) merchantNo(ctx context.Context, options *requestOptions) string {
+	if strings.TrimSpace(options.merchantNo) != "" {
+		return options.merchantNo
+	}
+	if merchantNo := MerchantNoFromContext(ctx); strings.TrimSpace(merchantNo) != "" {
+		return merchantNo
+	}
+	return s.config.MerchantNo
+}
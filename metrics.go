@@ -0,0 +1,54 @@
+package haozpay
+
+import (
+	"context"
+	"time"
+)
+
+// MetricsObserver 请求指标观察者
+// 用于接入 Prometheus 等监控系统，统计请求数、错误码分布和耗时
+// 每次接口调用只回调一次 OnRequestStart 和 OnRequestEnd，内部重试不会重复回调
+//
+// 注意:
+//   - 回调在请求所在的 goroutine 中同步执行，实现应尽量轻量且并发安全
+//
+// 示例:
+//
+//	type promObserver struct{}
+//
+//	func (promObserver) OnRequestStart(ctx context.Context, op sdk.Operation, path string) {}
+//
+//	func (promObserver) OnRequestEnd(ctx context.Context, m sdk.RequestMetrics) {
+//	    requestDuration.WithLabelValues(m.Path, strconv.Itoa(m.Code)).Observe(m.Duration.Seconds())
+//	}
+//
+//	config.WithMetricsObserver(promObserver{})
+type MetricsObserver interface {
+	// OnRequestStart 请求开始时调用
+	OnRequestStart(ctx context.Context, op Operation, path string)
+	// OnRequestEnd 请求结束时调用，无论成功或失败
+	OnRequestEnd(ctx context.Context, metrics RequestMetrics)
+}
+
+// RequestMetrics 单次接口调用的指标
+type RequestMetrics struct {
+	// Operation 接口操作
+	Operation Operation
+	// Path 接口路径，例如 /pay-core/payment/order
+	Path string
+	// StatusCode HTTP 状态码，请求未收到响应时为 0
+	StatusCode int
+	// Code 业务码，成功时为 0，失败时与返回错误的 SDKError.Code 一致
+	Code int
+	// Duration 调用耗时，包含签名、限流等待和重试
+	Duration time.Duration
+	// Err 调用返回的错误，成功时为 nil
+	Err error
+}
+
+// noopMetricsObserver 未配置观察者时使用的空实现
+type noopMetricsObserver struct{}
+
+func (noopMetricsObserver) OnRequestStart(context.Context, Operation, string) {}
+
+func (noopMetricsObserver) OnRequestEnd(context.Context, RequestMetrics) {}
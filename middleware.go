@@ -1,72 +1,322 @@
 package haozpay
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"crypto/rsa"
-	"crypto/sha256"
+	"crypto/subtle"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"net/http"
+	"net/url"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
 // signatureMiddleware 请求签名中间件
 // 在每个请求发送前自动添加签名字段
+// 签名写入请求对象的副本，原请求对象不会被修改，可安全地在多个 goroutine 间复用
+// 请求体不是 *HaozPayRequest 时(如 GET 接口)改为对查询参数签名，见 signQueryParams
 //
 // 皓臻支付签名算法:
 //  1. 收集请求参数(排除sign字段)
 //  2. 按参数名ASCII码升序排序
 //  3. 按"key=value"格式用&拼接成字符串
 //  4. 用SHA256算法生成摘要
-//  5. 用商户私钥对摘要进行RSA加密
+//  5. 用商户私钥对摘要进行RSA加密(配置了 Signer 时由其完成)
+//
+// 调试模式下会打印参与签名的原始字符串(即 BuildSignString 的结果)，
+// 便于与服务端期望的签名字符串比对
 //
 // 参数:
-//   - privateKeyPEM: 商户私钥(PEM格式)
+//   - signer: 签名器，默认使用商户 PEM 私钥
+//   - fallback: 备用签名器，请求上下文带有备用签名标记时使用，未配置时为 nil
+//   - debug: 是否开启调试模式
+//   - location: 签名的传递位置
+//   - header: 签名写入请求头时使用的请求头
+//   - signHeaders: 签名写入请求头时，规范请求头集合(见 signedHeaders)是否参与签名
 //
 // 返回:
 //   - resty.RequestMiddleware: resty 请求中间件函数
-func signatureMiddleware(privateKeyPEM string) resty.RequestMiddleware {
+func signatureMiddleware(signer, fallback Signer, debug bool, location SignatureLocation, header string, signHeaders bool) resty.RequestMiddleware {
 	return func(c *resty.Client, r *resty.Request) error {
-		if r.Body == nil {
-			return nil
-		}
+		signer := selectSigner(r.Context(), signer, fallback)
 
 		haozReq, ok := r.Body.(*HaozPayRequest)
 		if !ok {
+			// 没有 JSON 请求体的请求(如 GET 接口)按查询参数签名
+			return signQueryParams(r, signer, debug, location, header, signHeaders)
+		}
+
+		sign, err := signHaozPayRequest(r.Context(), haozReq, r.Header, signer, debug, location, signHeaders)
+		if err != nil {
+			return err
+		}
+
+		// 签名写入请求头时请求体保持不变
+		if location == SignatureInHeader {
+			r.SetHeader(header, sign)
 			return nil
 		}
 
-		paramsMap := make(map[string]interface{})
+		// 在副本上设置签名，不修改调用方传入的请求对象，
+		// 避免多个 goroutine 复用同一个 *HaozPayRequest 时产生数据竞争
+		signed := *haozReq
+		signed.Sign = sign
+		r.SetBody(&signed)
+
+		return nil
+	}
+}
+
+// signHaozPayRequest 生成 HaozPayRequest 的签名
+// 签名中间件和 SigningTransport 共用，签名写入请求头时同时在 header 中设置元信息请求头
+//
+// 参数:
+//   - ctx: 请求上下文，决定签名参数的范围(见 scopeSignParams)
+//   - haozReq: 待签名的请求，不会被修改
+//   - header: 请求头，签名写入请求头时写入商户编号、时间戳和随机数
+//   - signer: 签名器
+//   - debug: 是否打印签名字符串
+//   - location: 签名的传递位置
+//   - signHeaders: 规范请求头集合是否参与签名
+//
+// 返回:
+//   - string: Base64 编码的签名
+//   - error: 解析 bizBody 或签名失败时返回错误
+func signHaozPayRequest(ctx context.Context, haozReq *HaozPayRequest, header http.Header, signer Signer, debug bool, location SignatureLocation, signHeaders bool) (string, error) {
+	paramsMap, err := buildSignParams(haozReq)
+	if err != nil {
+		return "", err
+	}
+	paramsMap = scopeSignParams(ctx, paramsMap)
+
+	// 签名写入请求头时先设置元信息请求头，开启 signHeaders 时按实际发送的值参与签名
+	if location == SignatureInHeader {
+		header.Set(HeaderMerchantNo, haozReq.MerchantNo)
+		header.Set(HeaderTimestamp, strconv.FormatInt(haozReq.Timestamp, 10))
+		if haozReq.Nonce != "" {
+			header.Set(HeaderNonce, haozReq.Nonce)
+		}
+		if signHeaders {
+			addSignedHeaders(paramsMap, header)
+		}
+	}
+
+	if debug {
+		fmt.Printf("[SDK Sign String] %s\n", BuildSignString(paramsMap))
+	}
 
-		// 展开 bizBody JSON 到 paramsMap
-		if haozReq.BizBody != "" {
-			var bizBodyMap map[string]interface{}
-			if err := json.Unmarshal([]byte(haozReq.BizBody), &bizBodyMap); err != nil {
-				return fmt.Errorf("failed to unmarshal bizBody: %w", err)
+	sign, err := generateSignWithSigner(paramsMap, signer)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate signature: %w", err)
+	}
+	return sign, nil
+}
+
+// signQueryParams 对查询参数签名
+// 查询参数(排除 sign)按与请求体相同的规则生成签名，签名写入 sign 查询参数或请求头；
+// 没有查询参数时不签名。merchantNo、timestamp 等参数需要由调用方作为查询参数传入，
+// 开启 signHeaders 时调用方设置的规范请求头同样参与签名
+func signQueryParams(r *resty.Request, signer Signer, debug bool, location SignatureLocation, header string, signHeaders bool) error {
+	paramsMap, err := buildQuerySignParams(r.QueryParam)
+	if err != nil {
+		return fmt.Errorf("failed to generate signature: %w", err)
+	}
+	paramsMap = scopeSignParams(r.Context(), paramsMap)
+	if location == SignatureInHeader && signHeaders {
+		addSignedHeaders(paramsMap, r.Header)
+	}
+	if len(paramsMap) == 0 {
+		return nil
+	}
+
+	if debug {
+		fmt.Printf("[SDK Sign String] %s\n", BuildSignString(paramsMap))
+	}
+
+	sign, err := generateSignWithSigner(paramsMap, signer)
+	if err != nil {
+		return fmt.Errorf("failed to generate signature: %w", err)
+	}
+
+	if location == SignatureInHeader {
+		r.SetHeader(header, sign)
+		return nil
+	}
+	r.SetQueryParam("sign", sign)
+	return nil
+}
+
+// buildQuerySignParams 收集查询参数中参与签名的参数(排除 sign)
+func buildQuerySignParams(query url.Values) (map[string]interface{}, error) {
+	paramsMap := make(map[string]interface{}, len(query))
+	for key, values := range query {
+		if key == "sign" || len(values) == 0 {
+			continue
+		}
+		// 签名字符串中每个参数只出现一次，多值参数无法与服务端的签名结果一致
+		if len(values) > 1 {
+			return nil, fmt.Errorf("query parameter %s has multiple values", key)
+		}
+		paramsMap[key] = values[0]
+	}
+	return paramsMap, nil
+}
+
+// dryRunURL 拼接试运行结果中的请求地址，GET 接口的查询参数在中间件之后才合并到地址中，这里提前拼接
+func dryRunURL(rawURL string, query url.Values) string {
+	if len(query) == 0 {
+		return rawURL
+	}
+	return rawURL + "?" + query.Encode()
+}
+
+// buildSignParams 收集请求中参与签名的参数
+// 包括 bizBody 展开后的所有字段，以及 merchantNo、timestamp 和 nonce(设置时)
+func buildSignParams(haozReq *HaozPayRequest) (map[string]interface{}, error) {
+	paramsMap := make(map[string]interface{})
+
+	// 展开 bizBody JSON 到 paramsMap
+	// 数字保留为 json.Number，签名时使用 JSON 中的原始文本，避免大数被格式化为科学计数法
+	if haozReq.BizBody != "" {
+		var bizBodyMap map[string]interface{}
+		decoder := json.NewDecoder(strings.NewReader(haozReq.BizBody))
+		decoder.UseNumber()
+		if err := decoder.Decode(&bizBodyMap); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal bizBody: %w", err)
+		}
+		// 将 bizBody 中的所有字段添加到 paramsMap
+		for k, v := range bizBodyMap {
+			paramsMap[k] = v
+		}
+	}
+
+	// 添加 merchantNo 和 timestamp（使用数字类型，不是字符串）
+	paramsMap["merchantNo"] = haozReq.MerchantNo
+	paramsMap["timestamp"] = haozReq.Timestamp
+	// nonce 按参数名与其他参数一起排序，未设置时不参与签名
+	if haozReq.Nonce != "" {
+		paramsMap["nonce"] = haozReq.Nonce
+	}
+
+	return paramsMap, nil
+}
+
+// dryRunMiddleware 试运行中间件
+// 必须注册在签名中间件之后，试运行时以 DryRunError 中止请求并返回签名后的请求内容
+//
+// 参数:
+//   - signHeaders: 规范请求头是否参与签名，与签名中间件一致
+//
+// 返回:
+//   - resty.RequestMiddleware: resty 请求中间件函数
+func dryRunMiddleware(signHeaders bool) resty.RequestMiddleware {
+	return func(c *resty.Client, r *resty.Request) error {
+		if !isDryRun(r.Context()) {
+			return nil
+		}
+
+		var body []byte
+		if r.Body != nil {
+			var err error
+			if body, err = json.Marshal(r.Body); err != nil {
+				return fmt.Errorf("failed to marshal request body: %w", err)
+			}
+		}
+		// 表单编码在试运行之后进行，这里按实际发送的格式生成请求体
+		header := r.Header.Clone()
+		if haozReq, ok := r.Body.(*HaozPayRequest); ok && isFormEncoding(r.Context()) {
+			form, err := encodeFormBody(haozReq)
+			if err != nil {
+				return fmt.Errorf("failed to encode form body: %w", err)
+			}
+			body = []byte(form)
+			header.Set("Content-Type", formContentType)
+		}
+
+		dryRun := &DryRunError{
+			Method: r.Method,
+			URL:    dryRunURL(requestURL(c, r), r.QueryParam),
+			Header: header,
+			Body:   body,
+		}
+
+		if haozReq, ok := r.Body.(*HaozPayRequest); ok {
+			paramsMap, err := buildSignParams(haozReq)
+			if err != nil {
+				return err
+			}
+			paramsMap = scopeSignParams(r.Context(), paramsMap)
+			if signHeaders {
+				addSignedHeaders(paramsMap, r.Header)
 			}
-			// 将 bizBody 中的所有字段添加到 paramsMap
-			for k, v := range bizBodyMap {
-				paramsMap[k] = v
+			dryRun.Request = haozReq
+			dryRun.SignString = BuildSignString(paramsMap)
+		} else if len(r.QueryParam) > 0 {
+			paramsMap, err := buildQuerySignParams(r.QueryParam)
+			if err != nil {
+				return err
 			}
+			paramsMap = scopeSignParams(r.Context(), paramsMap)
+			if signHeaders {
+				addSignedHeaders(paramsMap, r.Header)
+			}
+			dryRun.SignString = BuildSignString(paramsMap)
 		}
 
-		// 添加 merchantNo 和 timestamp（使用数字类型，不是字符串）
-		paramsMap["merchantNo"] = haozReq.MerchantNo
-		paramsMap["timestamp"] = haozReq.Timestamp
+		return dryRun
+	}
+}
 
-		sign, err := GenerateSign(paramsMap, privateKeyPEM)
+// gzipMiddleware 请求体压缩中间件
+// 必须注册在签名中间件之后，签名基于压缩前的 bizBody 计算
+//
+// 处理逻辑:
+//  1. 将签名后的请求序列化为 JSON
+//  2. 长度达到阈值时使用 gzip 压缩，并设置 Content-Encoding: gzip
+//  3. 未达到阈值时保持原样发送
+//
+// 参数:
+//   - threshold: 压缩阈值(字节)
+//
+// 返回:
+//   - resty.RequestMiddleware: resty 请求中间件函数
+func gzipMiddleware(threshold int) resty.RequestMiddleware {
+	return func(c *resty.Client, r *resty.Request) error {
+		haozReq, ok := r.Body.(*HaozPayRequest)
+		if !ok {
+			return nil
+		}
+
+		body, err := json.Marshal(haozReq)
 		if err != nil {
-			return fmt.Errorf("failed to generate signature: %w", err)
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		if len(body) < threshold {
+			return nil
 		}
 
-		haozReq.Sign = sign
-		r.SetBody(haozReq)
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return fmt.Errorf("failed to gzip request body: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to gzip request body: %w", err)
+		}
 
+		r.SetHeader("Content-Encoding", "gzip")
+		r.SetBody(buf.Bytes())
 		return nil
 	}
 }
@@ -75,17 +325,30 @@ func signatureMiddleware(privateKeyPEM string) resty.RequestMiddleware {
 // 验签算法流程:
 //  1. 构建签名字符串(按参数名ASCII升序排序)
 //  2. 计算SHA256摘要
-//  3. 使用平台公钥解密签名
+//  3. 由验证器验证签名，默认使用平台公钥解密签名
 //  4. 比较解密后的摘要与计算的摘要是否一致
 //
 // 参数:
-//   - publicKeyPEM: 平台公钥(PEM格式)
+//   - verifier: 签名验证器
 //   - params: 回调参数(不含sign字段)
-//   - signature: Base64编码的签名字符串
+//   - signature: Base64编码的签名字符串，支持标准和 URL 安全两种编码
 //
 // 返回:
 //   - error: 验签失败时返回错误
-func verifyHaozPaySignature(publicKeyPEM string, params map[string]string, signature string) error {
+func verifyHaozPaySignature(verifier Verifier, params map[string]string, signature string) error {
+	hashHex := signDigest(verifySignString(params))
+
+	sigBytes, err := decodeSignature(signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	return verifier.Verify([]byte(hashHex), sigBytes)
+}
+
+// verifySignString 构建验签使用的签名字符串
+// 按参数名升序排列，值为空字符串的参数不参与签名
+func verifySignString(params map[string]string) string {
 	keys := make([]string, 0, len(params))
 	for k := range params {
 		keys = append(keys, k)
@@ -93,10 +356,11 @@ func verifyHaozPaySignature(publicKeyPEM string, params map[string]string, signa
 	sort.Strings(keys)
 
 	var sb strings.Builder
-	for i, key := range keys {
+	for _, key := range keys {
 		value := params[key]
 		if value != "" {
-			if i > 0 {
+			// 以已写入的内容判断是否需要分隔符，避免首个参数为空时签名字符串以 & 开头
+			if sb.Len() > 0 {
 				sb.WriteString("&")
 			}
 			sb.WriteString(key)
@@ -105,26 +369,35 @@ func verifyHaozPaySignature(publicKeyPEM string, params map[string]string, signa
 		}
 	}
 
-	paramsStr := sb.String()
-	hash := sha256.Sum256([]byte(paramsStr))
-	hashHex := fmt.Sprintf("%x", hash)
-
-	publicKey, err := parsePublicKey(publicKeyPEM)
-	if err != nil {
-		return fmt.Errorf("failed to parse public key: %w", err)
-	}
+	return sb.String()
+}
 
+// decodeSignature 解码 Base64 编码的签名
+// 先按标准 Base64 解码，失败时按 URL 安全的 Base64(使用 - 和 _)解码，
+// 部分响应和同步跳转地址中的签名使用后者，且常省略末尾的 = 填充，两种编码都接受不带填充的形式；
+// 全部失败时返回标准 Base64 的解码错误
+func decodeSignature(signature string) ([]byte, error) {
 	sigBytes, err := base64.StdEncoding.DecodeString(signature)
-	if err != nil {
-		return fmt.Errorf("failed to decode signature: %w", err)
+	if err == nil {
+		return sigBytes, nil
 	}
+	for _, encoding := range []*base64.Encoding{base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if decoded, decodeErr := encoding.DecodeString(signature); decodeErr == nil {
+			return decoded, nil
+		}
+	}
+	return nil, err
+}
 
-	decrypted, err := decryptWithPublicKey(publicKey, sigBytes)
+// verifyDigest 使用公钥解密签名，并与摘要比较
+func verifyDigest(publicKey *rsa.PublicKey, digest, sig []byte) error {
+	decrypted, err := decryptWithPublicKey(publicKey, sig)
 	if err != nil {
 		return fmt.Errorf("failed to decrypt with public key: %w", err)
 	}
 
-	if string(decrypted) != hashHex {
+	// 使用常量时间比较，避免通过比较耗时泄露摘要信息
+	if subtle.ConstantTimeCompare(decrypted, digest) != 1 {
 		return fmt.Errorf("signature verification failed: hash mismatch")
 	}
 
@@ -134,7 +407,18 @@ func verifyHaozPaySignature(publicKeyPEM string, params map[string]string, signa
 // decryptWithPublicKey 使用公钥解密数据
 // 这是非标准的RSA用法，但与Java的Hutool库行为一致
 // Java的Hutool库实际上是用公钥做"验签"操作（textbook RSA）
+//
+// 解密后校验并去除签名方添加的PKCS1v15填充（block type 1）:
+//
+//	0x00 || 0x01 || PS(至少8个0xFF) || 0x00 || M
+//
+// 大整数会去掉前导零，解密结果先左补零到密钥长度，再按固定位置校验填充
 func decryptWithPublicKey(publicKey *rsa.PublicKey, data []byte) ([]byte, error) {
+	k := publicKey.Size()
+	if len(data) == 0 || len(data) > k {
+		return nil, fmt.Errorf("invalid signature length: %d bytes, expected %d", len(data), k)
+	}
+
 	c := new(big.Int).SetBytes(data)
 	if c.Cmp(publicKey.N) >= 0 {
 		return nil, fmt.Errorf("message too long")
@@ -142,16 +426,55 @@ func decryptWithPublicKey(publicKey *rsa.PublicKey, data []byte) ([]byte, error)
 
 	// 使用公钥的 E 和 N 进行模幂运算: m = c^e mod n
 	m := new(big.Int).Exp(c, big.NewInt(int64(publicKey.E)), publicKey.N)
+	em := m.FillBytes(make([]byte, k))
+
+	// 校验前导零和 block type
+	if em[0] != 0x00 || em[1] != 0x01 {
+		return nil, fmt.Errorf("invalid signature padding: unexpected block type")
+	}
+
+	// 跳过 0xFF 填充，查找分隔符 0x00
+	sep := 2
+	for sep < len(em) && em[sep] == 0xFF {
+		sep++
+	}
+	if sep == len(em) || em[sep] != 0x00 {
+		return nil, fmt.Errorf("invalid signature padding: missing separator")
+	}
+	if sep-2 < 8 {
+		return nil, fmt.Errorf("invalid signature padding: padding too short")
+	}
+
+	// 返回去除填充后的原始数据
+	return em[sep+1:], nil
+}
+
+// decryptChunkedWithPublicKey 使用公钥分段解密 privateKeyEncryptChunked 生成的数据
+// 密文按 k 字节切分，逐段调用 decryptWithPublicKey 后按顺序拼接
+func decryptChunkedWithPublicKey(publicKey *rsa.PublicKey, data []byte) ([]byte, error) {
+	k := publicKey.Size()
+	if len(data)%k != 0 {
+		return nil, fmt.Errorf("invalid ciphertext length: %d bytes is not a multiple of %d", len(data), k)
+	}
 
-	// 去除前导零，返回原始数据
-	return m.Bytes(), nil
+	decrypted := make([]byte, 0, len(data))
+	for start := 0; start < len(data); start += k {
+		block, err := decryptWithPublicKey(publicKey, data[start:start+k])
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", start/k+1, err)
+		}
+		decrypted = append(decrypted, block...)
+	}
+	return decrypted, nil
 }
 
 // parsePublicKey 解析PEM格式的公钥
 // 支持两种格式:
 //  1. 完整的 PEM 格式(带 -----BEGIN/END----- 标志)
 //  2. 纯 Base64 编码的密钥字符串(不带标志)
-func parsePublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
+//
+// 模长小于 minBits 位时返回 ErrKeyParse 类错误
+func parsePublicKey(publicKeyPEM string, minBits int) (*rsa.PublicKey, error) {
 	var keyBytes []byte
 
 	// 尝试 PEM 解码
@@ -163,21 +486,24 @@ func parsePublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
 		// 可能是纯 Base64 格式，尝试直接解码
 		decoded, err := base64.StdEncoding.DecodeString(publicKeyPEM)
 		if err != nil {
-			return nil, fmt.Errorf("failed to decode public key: not valid PEM or Base64 format")
+			return nil, newLocalizedError(ErrKeyParse, msgPublicKeyFormat, nil)
 		}
 		keyBytes = decoded
 	}
 
 	pubInterface, err := x509.ParsePKIXPublicKey(keyBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse public key: %w", err)
+		return nil, newLocalizedError(ErrKeyParse, msgInvalidPublicKey, err)
 	}
 
 	pubKey, ok := pubInterface.(*rsa.PublicKey)
 	if !ok {
-		return nil, fmt.Errorf("not an RSA public key")
+		return nil, newLocalizedError(ErrKeyParse, msgNotRSAPublicKey, nil)
 	}
 
+	if err := checkKeyBits(pubKey, minBits); err != nil {
+		return nil, err
+	}
 	return pubKey, nil
 }
 
@@ -188,6 +514,7 @@ func parsePublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
 //  1. 检查 HTTP 状态码是否 >= 400
 //  2. 如果是错误状态，尝试解析响应体中的错误信息
 //  3. 将错误信息包装为 SDKError 类型返回
+//  4. 状态码为 429 时，解析 Retry-After 响应头并记录在 SDKError 中
 //
 // 返回:
 //   - resty.ResponseMiddleware: resty 响应中间件函数
@@ -195,25 +522,175 @@ func errorHandlerMiddleware() resty.ResponseMiddleware {
 	return func(c *resty.Client, r *resty.Response) error {
 		// 检查是否为错误状态码
 		if r.StatusCode() >= 400 {
-			var errResp Response
-
-			// 尝试解析错误响应
-			if err := json.Unmarshal(r.Body(), &errResp); err != nil {
-				// 解析失败时返回通用错误
-				return NewSDKError(
-					0,
-					"failed to parse error response",
-					r.StatusCode(),
-				)
+			return errorFromResponse(r, r.Body())
+		}
+		return nil
+	}
+}
+
+// errorFromResponse 将错误状态码的响应转换为 SDKError
+//
+// 参数:
+//   - r: 状态码不低于 400 的响应
+//   - body: 响应体
+//
+// 返回:
+//   - *SDKError: 能解析响应体时包含网关返回的业务码、信息和请求ID
+func errorFromResponse(r *resty.Response, body []byte) *SDKError {
+	var errResp Response
+	var sdkErr *SDKError
+
+	// 尝试解析错误响应
+	if err := json.Unmarshal(body, &errResp); err != nil {
+		// 解析失败时返回通用错误
+		sdkErr = NewSDKError(
+			0,
+			fmt.Sprintf("failed to parse error response, body: %s", bodySnippet(body)),
+			r.StatusCode(),
+		)
+	} else {
+		// 返回包含详细信息的 SDK 错误
+		sdkErr = NewSDKErrorWithRequestID(
+			errResp.Code,
+			errResp.Message,
+			r.StatusCode(),
+			errResp.RequestID,
+		)
+		sdkErr.Details = errResp.Details
+	}
+
+	if r.StatusCode() == http.StatusTooManyRequests {
+		sdkErr.retryAfter, _ = parseRetryAfter(r.Header().Get("Retry-After"), time.Now())
+	}
+	return sdkErr
+}
+
+// responseSignatureMiddleware 响应签名校验中间件
+// 在接收到响应后校验响应签名，防止响应内容被篡改或伪造
+//
+// 处理逻辑:
+//  1. 响应中不包含 sign 字段时跳过校验
+//  2. 将响应 data 对象的字段作为验签参数
+//  3. 使用验证器验签(默认使用平台公钥)，失败时返回 SDKError
+//
+// 参数:
+//   - verifier: 签名验证器
+//   - debug: 是否开启调试模式，开启后验签失败时打印响应体和签名字符串的 SHA256 摘要
+//   - signHeaders: 响应中的规范请求头集合(见 signedHeaders)是否参与验签
+//   - requireSign: 是否拒绝未签名的成功响应，关闭时未签名的响应直接放行
+//
+// 返回:
+//   - resty.ResponseMiddleware: resty 响应中间件函数
+func responseSignatureMiddleware(verifier Verifier, debug bool, signHeaders bool, requireSign bool) resty.ResponseMiddleware {
+	return func(c *resty.Client, r *resty.Response) error {
+		var signed struct {
+			Code json.RawMessage `json:"code"`
+			Data json.RawMessage `json:"data"`
+			Sign string          `json:"sign"`
+		}
+		if err := json.Unmarshal(r.Body(), &signed); err != nil || signed.Sign == "" {
+			if !requireSign {
+				return nil
+			}
+			// 业务失败的响应交给调用方按业务错误处理，只拒绝无法确认来源的成功响应
+			if err == nil {
+				if code, codeErr := parseResponseCode(signed.Code); codeErr == nil && code != 0 {
+					return nil
+				}
+			}
+			return &SDKError{
+				Code:       ErrInvalidSign.Code,
+				Message:    "response is not signed",
+				StatusCode: r.StatusCode(),
+			}
+		}
+
+		// 使用 UseNumber 保留数字的原始文本，避免大整数被渲染为科学计数法
+		dataMap := make(map[string]interface{})
+		if bytes.HasPrefix(bytes.TrimSpace(signed.Data), []byte("{")) {
+			decoder := json.NewDecoder(bytes.NewReader(signed.Data))
+			decoder.UseNumber()
+			if err := decoder.Decode(&dataMap); err != nil {
+				return &SDKError{
+					Code:       ErrInvalidResponse.Code,
+					Message:    fmt.Sprintf("failed to decode response data: %v", err),
+					StatusCode: r.StatusCode(),
+				}
+			}
+		}
+
+		params := make(map[string]string, len(dataMap))
+		for k, v := range dataMap {
+			if v == nil {
+				continue
+			}
+			// 与请求签名使用相同的取值规则，嵌套对象和数组为规范 JSON 文本
+			params[k] = formatSignValue(v)
+		}
+		if signHeaders {
+			for k, v := range signedHeaderParams(r.Header()) {
+				params[k] = v
+			}
+		}
+
+		if err := verifyHaozPaySignature(verifier, params, signed.Sign); err != nil {
+			if debug {
+				// 响应体摘要与服务端记录的一致而签名字符串摘要不一致时，说明签名字符串的构建方式存在差异；
+				// 响应体摘要也不一致时，说明响应在传输中被修改
+				signString := verifySignString(params)
+				fmt.Printf("[SDK Response Verify Failed] Body SHA256: %s, Sign String SHA256: %s\n",
+					signDigest(string(r.Body())), signDigest(signString))
+				fmt.Printf("[SDK Response Sign String] %s\n", signString)
+			}
+			return &SDKError{
+				Code:       ErrInvalidSign.Code,
+				Message:    fmt.Sprintf("response signature verification failed: %v", err),
+				StatusCode: r.StatusCode(),
+			}
+		}
+
+		return nil
+	}
+}
+
+// rateLimitMiddleware 限流中间件
+// 在请求发送前获取令牌，超出速率时阻塞等待，等待受请求上下文的截止时间和取消控制
+//
+// 参数:
+//   - limiter: 令牌桶限流器
+//
+// 返回:
+//   - resty.RequestMiddleware: resty 请求中间件函数
+func rateLimitMiddleware(limiter *tokenBucket) resty.RequestMiddleware {
+	return func(c *resty.Client, r *resty.Request) error {
+		if err := limiter.wait(r.Context()); err != nil {
+			return &SDKError{
+				Code:       ErrRateLimited.Code,
+				Message:    fmt.Sprintf("client rate limit exceeded: %v", err),
+				StatusCode: 0,
 			}
+		}
+		return nil
+	}
+}
 
-			// 返回包含详细信息的 SDK 错误
-			return NewSDKErrorWithRequestID(
-				errResp.Code,
-				errResp.Message,
-				r.StatusCode(),
-				errResp.RequestID,
-			)
+// requestIDMiddleware 请求ID中间件
+// 从请求上下文中获取请求ID，并通过配置的请求头发送给网关
+// 已通过请求选项显式设置该请求头时不覆盖
+//
+// 参数:
+//   - cfg: SDK 配置，提供请求头名称和请求ID获取函数
+//
+// 返回:
+//   - resty.RequestMiddleware: resty 请求中间件函数
+func requestIDMiddleware(cfg *Config) resty.RequestMiddleware {
+	header := cfg.requestIDHeader()
+	return func(c *resty.Client, r *resty.Request) error {
+		if r.Header.Get(header) != "" {
+			return nil
+		}
+		if requestID := cfg.requestID(r.Context()); requestID != "" {
+			r.SetHeader(header, requestID)
 		}
 		return nil
 	}
@@ -224,23 +701,31 @@ func errorHandlerMiddleware() resty.ResponseMiddleware {
 //
 // 打印内容:
 //   - 请求方法和 URL
+//   - 请求ID(如有)
 //   - 请求体内容(格式化的 JSON)
 //
 // 参数:
 //   - debug: 是否开启调试模式
+//   - requestIDHeader: 透传请求ID使用的请求头
+//   - bodyLimit: 打印的最大字节数，0 表示不限制，见 formatLogBody
 //
 // 返回:
 //   - resty.RequestMiddleware: resty 请求中间件函数
-func requestLogMiddleware(debug bool) resty.RequestMiddleware {
+func requestLogMiddleware(debug bool, requestIDHeader string, bodyLimit int) resty.RequestMiddleware {
 	return func(c *resty.Client, r *resty.Request) error {
 		if debug {
 			// 打印请求行
 			fmt.Printf("[SDK Request] %s %s\n", r.Method, r.URL)
 
+			// 打印请求ID
+			if requestID := r.Header.Get(requestIDHeader); requestID != "" {
+				fmt.Printf("[SDK Request ID] %s\n", requestID)
+			}
+
 			// 打印请求体
 			if r.Body != nil {
 				bodyBytes, _ := json.MarshalIndent(r.Body, "", "  ")
-				fmt.Printf("[SDK Request Body] %s\n", string(bodyBytes))
+				fmt.Printf("[SDK Request Body] %s\n", formatLogBody(bodyBytes, bodyLimit))
 			}
 		}
 		return nil
@@ -253,22 +738,30 @@ func requestLogMiddleware(debug bool) resty.RequestMiddleware {
 // 打印内容:
 //   - HTTP 状态码
 //   - 请求耗时
+//   - 请求ID(如有)
 //   - 响应体内容
 //
 // 参数:
 //   - debug: 是否开启调试模式
+//   - requestIDHeader: 透传请求ID使用的请求头
+//   - bodyLimit: 打印的最大字节数，0 表示不限制，见 formatLogBody
 //
 // 返回:
 //   - resty.ResponseMiddleware: resty 响应中间件函数
-func responseLogMiddleware(debug bool) resty.ResponseMiddleware {
+func responseLogMiddleware(debug bool, requestIDHeader string, bodyLimit int) resty.ResponseMiddleware {
 	return func(c *resty.Client, r *resty.Response) error {
 		if debug {
 			// 打印响应状态和耗时
 			fmt.Printf("[SDK Response] Status: %d, Time: %v\n",
 				r.StatusCode(), r.Time())
 
+			// 打印请求ID
+			if requestID := r.Request.Header.Get(requestIDHeader); requestID != "" {
+				fmt.Printf("[SDK Request ID] %s\n", requestID)
+			}
+
 			// 打印响应体
-			fmt.Printf("[SDK Response Body] %s\n", string(r.Body()))
+			fmt.Printf("[SDK Response Body] %s\n", formatLogBody(r.Body(), bodyLimit))
 		}
 		return nil
 	}
@@ -0,0 +1,140 @@
+package haozpay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// TestRequireResponseSignature 开启严格模式后拒绝未签名的成功响应，业务失败的响应仍返回业务错误
+func TestRequireResponseSignature(t *testing.T) {
+	key, _, _ := testKeyPair(t)
+	data := map[string]interface{}{"orderNo": "ORDER001", "refundAmount": json.Number("1.5")}
+	sign, err := generateSignWithKey(data, key)
+	if err != nil {
+		t.Fatalf("generateSignWithKey: %v", err)
+	}
+	signedBody := fmt.Sprintf(`{"code":0,"message":"ok","data":{"orderNo":"ORDER001","refundAmount":1.5},"sign":%q}`, sign)
+	unsignedBody := `{"code":0,"message":"ok","data":{"orderNo":"ORDER001","refundAmount":1.5}}`
+	failedBody := `{"code":4001,"message":"bad request"}`
+
+	tests := []struct {
+		name    string
+		require bool
+		body    string
+		// wantCode 为 0 时期望成功
+		wantCode int
+	}{
+		{name: "best effort signed", body: signedBody},
+		{name: "best effort unsigned", body: unsignedBody},
+		{name: "strict signed", require: true, body: signedBody},
+		{name: "strict unsigned", require: true, body: unsignedBody, wantCode: ErrInvalidSign.Code},
+		{name: "strict non-json", require: true, body: `<html>ok</html>`, wantCode: ErrInvalidSign.Code},
+		{name: "strict business failure", require: true, body: failedBody, wantCode: 4001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}, func(cfg *Config) {
+				cfg.WithVerifyResponses(true).WithRequireResponseSignature(tt.require)
+			})
+
+			_, err := client.Payment.QueryRefund(context.Background(), &QueryRefundRequest{OrderNo: "ORDER001"})
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("QueryRefund: %v", err)
+				}
+				return
+			}
+			var sdkErr *SDKError
+			if !errors.As(err, &sdkErr) || sdkErr.Code != tt.wantCode {
+				t.Fatalf("QueryRefund err = %v, want code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+// TestRequireResponseSignatureNeedsVerifyResponses 未开启 VerifyResponses 时不能开启严格模式
+func TestRequireResponseSignatureNeedsVerifyResponses(t *testing.T) {
+	cfg := DefaultConfig().
+		WithBaseURL("https://gate.example.com").
+		WithMerchantNo("M1").
+		WithPrivateKey("key").
+		WithPlatFormPublicKey("key").
+		WithRequireResponseSignature(true)
+	var cfgErr *ConfigError
+	if err := cfg.Validate(); !errors.As(err, &cfgErr) {
+		t.Fatalf("Validate() = %v, want *ConfigError", err)
+	}
+}
+
+// TestUserMiddlewareOrder 签名前的中间件看到未签名的请求体且修改会参与签名，签名后的中间件看到已签名的请求体
+func TestUserMiddlewareOrder(t *testing.T) {
+	key, _, _ := testKeyPair(t)
+	verifier := NewPublicKeyVerifier(&key.PublicKey)
+
+	var calls []string
+	// record 按请求体是否已签名记录中间件的调用
+	record := func(name string) resty.RequestMiddleware {
+		return func(c *resty.Client, r *resty.Request) error {
+			req, ok := r.Body.(*HaozPayRequest)
+			if !ok {
+				t.Errorf("%s: body is %T, want *HaozPayRequest", name, r.Body)
+				return nil
+			}
+			state := "unsigned"
+			if req.Sign != "" {
+				state = "signed"
+			}
+			calls = append(calls, name+":"+state)
+			return nil
+		}
+	}
+	// addRemark 在签名前向 bizBody 添加字段，该字段应参与签名
+	addRemark := func(c *resty.Client, r *resty.Request) error {
+		req := r.Body.(*HaozPayRequest)
+		modified := *req
+		modified.BizBody = strings.TrimSuffix(req.BizBody, "}") + `,"remark":"added"}`
+		r.SetBody(&modified)
+		return nil
+	}
+
+	var sent HaozPayRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		okHandler(w, r)
+	}, func(cfg *Config) {
+		cfg.WithBeforeSignMiddleware(record("before1"), addRemark, record("before2")).
+			WithAfterSignMiddleware(record("after1"), record("after2"))
+	})
+
+	if err := client.Payment.CancelOrder(context.Background(), &CancelPaymentOrderRequest{OrderNo: "ORDER001"}); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+
+	want := []string{"before1:unsigned", "before2:unsigned", "after1:signed", "after2:signed"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	if !strings.Contains(sent.BizBody, `"remark":"added"`) {
+		t.Fatalf("bizBody = %s, want the field added before signing", sent.BizBody)
+	}
+	params, err := buildSignParams(&sent)
+	if err != nil {
+		t.Fatalf("buildSignParams: %v", err)
+	}
+	if err := verifyHaozPaySignature(verifier, stringParams(params), sent.Sign); err != nil {
+		t.Fatalf("signature does not cover the before-sign change: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package haozpay
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AckResponder 回调应答
+// 商户处理完异步通知后需要向平台返回约定的应答内容，应答不符合约定时平台会认为通知失败并持续重试
+// 不同的通知接口约定的应答可能不同，可选择预定义的应答或自定义
+//
+// 示例:
+//
+//	if err := client.VerifyCallback(params, signature); err != nil {
+//	    http.Error(w, "fail", http.StatusBadRequest)
+//	    return
+//	}
+//	// 处理业务逻辑...
+//	sdk.AckSuccess.Write(w)
+type AckResponder struct {
+	// ContentType 应答的 Content-Type
+	ContentType string
+	// Body 应答内容
+	Body string
+}
+
+var (
+	// AckSuccess 纯文本小写 success 应答
+	AckSuccess = AckResponder{ContentType: "text/plain; charset=utf-8", Body: "success"}
+	// AckSuccessUpper 纯文本大写 SUCCESS 应答
+	AckSuccessUpper = AckResponder{ContentType: "text/plain; charset=utf-8", Body: "SUCCESS"}
+	// AckJSON JSON 格式 {"code":0} 应答
+	AckJSON = AckResponder{ContentType: "application/json", Body: `{"code":0}`}
+)
+
+// Write 以 HTTP 200 写出应答
+//
+// 参数:
+//   - w: 回调请求的响应
+//
+// 返回:
+//   - error: 写入失败时返回错误
+func (a AckResponder) Write(w http.ResponseWriter) error {
+	if a.ContentType != "" {
+		w.Header().Set("Content-Type", a.ContentType)
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write([]byte(a.Body))
+	return err
+}
+
+var (
+	// ErrNotificationExpired 回调时间戳缺失或超出 MaxClockSkew 允许的范围
+	ErrNotificationExpired = errors.New("notification timestamp outside allowed window")
+	// ErrDuplicateNotification 回调已经处理过，由 NonceCache 判定
+	// 平台重试投递同一通知时也会返回该错误，商户应直接应答成功而不重复处理业务
+	ErrDuplicateNotification = errors.New("duplicate notification")
+)
+
+// NonceCache 回调去重缓存
+// VerifyCallback 以回调参数的签名摘要作为 nonce：同一通知的重复投递摘要相同，而内容不同的通知摘要不同
+// 多实例部署时应使用共享存储(如 Redis)实现，否则只能在单个实例内去重
+type NonceCache interface {
+	// CheckAndStore 记录 nonce 并返回此前是否已经记录过，检查和记录必须是原子的
+	// ttl 为记录至少需要保留的时间，为 0 时表示没有配置 MaxClockSkew，由实现自行决定保留时间
+	CheckAndStore(nonce string, ttl time.Duration) (seen bool, err error)
+}
+
+// checkNotificationFreshness 检查回调时间戳是否在允许的范围内
+//
+// 参数:
+//   - params: 已验签的回调参数
+//   - now: 当前时间
+//   - maxSkew: 允许的最大偏差
+//
+// 返回:
+//   - error: 时间戳缺失、无效或超出范围时返回包装 ErrNotificationExpired 的错误
+func checkNotificationFreshness(params map[string]string, now time.Time, maxSkew time.Duration) error {
+	value, ok := params["timestamp"]
+	if !ok || value == "" {
+		return fmt.Errorf("%w: timestamp is missing", ErrNotificationExpired)
+	}
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp %q", ErrNotificationExpired, value)
+	}
+
+	skew := now.Sub(time.UnixMilli(millis))
+	if skew > maxSkew || skew < -maxSkew {
+		return fmt.Errorf("%w: timestamp %d is %v away from local time", ErrNotificationExpired, millis, skew)
+	}
+	return nil
+}
+
+// notificationNonce 返回回调的去重标识，即已验签参数的签名摘要
+// 同一签名有多种 Base64 写法(标准或 URL 安全、带或不带填充)都能通过验签，不能以签名文本去重
+func notificationNonce(params map[string]string) string {
+	return signDigest(verifySignString(params))
+}
+
+// checkNotificationNonce 使用 NonceCache 检查回调是否重复投递
+func checkNotificationNonce(cache NonceCache, params map[string]string, maxSkew time.Duration) error {
+	// 超出时间窗口的重放会被时间戳检查拒绝，记录只需保留整个窗口的时长
+	seen, err := cache.CheckAndStore(notificationNonce(params), 2*maxSkew)
+	if err != nil {
+		return fmt.Errorf("nonce cache: %w", err)
+	}
+	if seen {
+		return ErrDuplicateNotification
+	}
+	return nil
+}
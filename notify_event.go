@@ -0,0 +1,294 @@
+package haozpay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultNotificationTypeParam 回调参数中标识通知事件类型的默认参数名
+// 注意: 平台通知文档尚未公布该参数名，notifyType 是未经确认的占位值，
+// 接入前应按平台提供的说明通过 Config.WithNotificationTypeParam 配置
+const DefaultNotificationTypeParam = "notifyType"
+
+// NotificationType 异步通知的事件类型
+// 取值以平台通知文档为准，平台新增的事件类型可直接使用 NotificationType("...") 比较
+type NotificationType string
+
+// 通知事件类型
+// 注意: 以下取值是未经平台文档确认的占位值，平台公布事件类型前应以实际收到的值为准，
+// 可直接比较 NotificationType("平台取值") 而不使用这些常量
+const (
+	// NotificationTypePayment 支付成功通知
+	NotificationTypePayment NotificationType = "PAYMENT"
+	// NotificationTypeRefund 退款结果通知
+	NotificationTypeRefund NotificationType = "REFUND"
+	// NotificationTypeDispute 交易争议通知
+	NotificationTypeDispute NotificationType = "DISPUTE"
+	// NotificationTypeSettlement 结算通知
+	NotificationTypeSettlement NotificationType = "SETTLEMENT"
+)
+
+// ErrNotificationType 读取的通知载荷与通知事件类型不一致
+var ErrNotificationType = errors.New("notification type mismatch")
+
+// Notification 已验签的异步通知
+// 同一个回调地址可能收到不同类型的事件，先通过 Type 判断事件类型，再使用对应的方法解析载荷
+//
+// 示例:
+//
+//	n, err := client.ParseNotification(r)
+//	if err != nil {
+//	    http.Error(w, "fail", http.StatusBadRequest)
+//	    return
+//	}
+//	switch n.Type() {
+//	case sdk.NotificationTypePayment:
+//	    payment, err := n.Payment()
+//	    // ...
+//	case sdk.NotificationTypeRefund:
+//	    refund, err := n.Refund()
+//	    // ...
+//	}
+type Notification struct {
+	// Params 已验签的回调参数(不包含 sign)
+	Params map[string]string
+
+	typeParam string
+}
+
+// PaymentNotification 支付成功通知的载荷
+type PaymentNotification struct {
+	MerchantNo string    `json:"merchantNo"`
+	OrderNo    string    `json:"orderNo"`
+	PayStatus  int       `json:"payStatus"`
+	PayAmount  float64   `json:"payAmount"`
+	Timestamp  Timestamp `json:"timestamp"`
+}
+
+// RefundNotification 退款结果通知的载荷
+type RefundNotification struct {
+	MerchantNo         string       `json:"merchantNo"`
+	OrderNo            string       `json:"orderNo"`
+	RefundSeqId        string       `json:"refundSeqId"`
+	RefundAmount       float64      `json:"refundAmount"`
+	ActualRefundAmount float64      `json:"actualRefundAmount"`
+	RefundStatus       RefundStatus `json:"refundStatus"`
+	Timestamp          Timestamp    `json:"timestamp"`
+}
+
+// DisputeNotification 交易争议通知的载荷
+// 注意: 平台尚未公布争议通知，字段名是未经确认的占位，接入时应使用 Notification.Decode 解析到按平台文档定义的结构体
+type DisputeNotification struct {
+	MerchantNo    string    `json:"merchantNo"`
+	OrderNo       string    `json:"orderNo"`
+	DisputeId     string    `json:"disputeId"`
+	DisputeAmount float64   `json:"disputeAmount"`
+	DisputeReason string    `json:"disputeReason"`
+	Timestamp     Timestamp `json:"timestamp"`
+}
+
+// SettlementNotification 结算通知的载荷
+// 注意: 平台尚未公布结算通知，字段名是未经确认的占位，接入时应使用 Notification.Decode 解析到按平台文档定义的结构体
+type SettlementNotification struct {
+	MerchantNo   string    `json:"merchantNo"`
+	SettleDate   string    `json:"settleDate"`
+	SettleAmount float64   `json:"settleAmount"`
+	FeeAmount    float64   `json:"feeAmount"`
+	Timestamp    Timestamp `json:"timestamp"`
+}
+
+// ParseNotification 解析并验签异步通知请求
+// 读取表单参数后按 VerifyCallbackWithHeaders 验签，同样执行时间戳和去重检查
+//
+// 参数:
+//   - r: 平台的异步通知请求
+//
+// 返回:
+//   - *Notification: 已验签的通知
+//   - error: 参数无法解析或验签失败时返回错误，重复投递时返回 ErrDuplicateNotification
+func (c *Client) ParseNotification(r *http.Request) (*Notification, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("parse notification form: %w", err)
+	}
+	params, signature, err := splitSignedValues(r.Form)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.VerifyCallbackWithHeaders(params, r.Header, signature); err != nil {
+		return nil, err
+	}
+	return c.WrapNotification(params), nil
+}
+
+// WrapNotification 将已验签的回调参数包装为 Notification
+// 用于 NotificationHandler 等已经完成验签的场景，不会再次验签
+//
+// 参数:
+//   - params: 已验签的回调参数
+//
+// 返回:
+//   - *Notification: 按 Config.NotificationTypeParam 识别事件类型的通知
+//
+// 示例:
+//
+//	handler := sdk.NewNotificationHandler(client, func(ctx context.Context, params map[string]string) error {
+//	    n := client.WrapNotification(params)
+//	    if n.Type() != sdk.NotificationTypeRefund {
+//	        return nil
+//	    }
+//	    refund, err := n.Refund()
+//	    // ...
+//	})
+func (c *Client) WrapNotification(params map[string]string) *Notification {
+	return &Notification{Params: params, typeParam: c.config.notificationTypeParam()}
+}
+
+// Type 返回通知的事件类型，参数缺失时返回空字符串
+func (n *Notification) Type() NotificationType {
+	param := n.typeParam
+	if param == "" {
+		param = DefaultNotificationTypeParam
+	}
+	return NotificationType(strings.ToUpper(strings.TrimSpace(n.Params[param])))
+}
+
+// Payment 解析支付成功通知的载荷
+// 事件类型不是 NotificationTypePayment 时返回包装 ErrNotificationType 的错误
+func (n *Notification) Payment() (*PaymentNotification, error) {
+	var v PaymentNotification
+	if err := n.decodeAs(NotificationTypePayment, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Refund 解析退款结果通知的载荷
+// 事件类型不是 NotificationTypeRefund 时返回包装 ErrNotificationType 的错误
+func (n *Notification) Refund() (*RefundNotification, error) {
+	var v RefundNotification
+	if err := n.decodeAs(NotificationTypeRefund, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Dispute 解析交易争议通知的载荷
+// 事件类型不是 NotificationTypeDispute 时返回包装 ErrNotificationType 的错误
+// 事件类型和载荷字段均为未经确认的占位，见 DisputeNotification
+func (n *Notification) Dispute() (*DisputeNotification, error) {
+	var v DisputeNotification
+	if err := n.decodeAs(NotificationTypeDispute, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Settlement 解析结算通知的载荷
+// 事件类型不是 NotificationTypeSettlement 时返回包装 ErrNotificationType 的错误
+// 事件类型和载荷字段均为未经确认的占位，见 SettlementNotification
+func (n *Notification) Settlement() (*SettlementNotification, error) {
+	var v SettlementNotification
+	if err := n.decodeAs(NotificationTypeSettlement, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Decode 按 json 标签将回调参数解析到自定义结构体
+// 用于 SDK 未预定义的事件类型或字段，回调参数均为字符串，按字段类型转换
+//
+// 参数:
+//   - v: 结构体指针，支持字符串、整数、浮点数、布尔字段以及实现 json.Unmarshaler 的字段
+//
+// 返回:
+//   - error: v 不是结构体指针或参数无法转换为字段类型时返回错误
+//
+// 注意:
+//   - 缺失的参数保持字段零值
+func (n *Notification) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("notification decode target must be a non-nil struct pointer, got %T", v)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		value, ok := n.Params[name]
+		if !ok {
+			continue
+		}
+		if err := setNotificationField(rv.Field(i), value); err != nil {
+			return fmt.Errorf("notification parameter %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// decodeAs 检查事件类型后解析载荷
+func (n *Notification) decodeAs(want NotificationType, v interface{}) error {
+	if got := n.Type(); got != want {
+		return fmt.Errorf("%w: notification type is %q, not %q", ErrNotificationType, got, want)
+	}
+	return n.Decode(v)
+}
+
+// setNotificationField 将字符串参数转换为字段类型
+func setNotificationField(field reflect.Value, value string) error {
+	if u, ok := field.Addr().Interface().(json.Unmarshaler); ok {
+		quoted, _ := json.Marshal(value)
+		return u.UnmarshalJSON(quoted)
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+		return nil
+	}
+	if value == "" {
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package haozpay
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultNotificationTTL 通知处理记录的默认保留时间，应大于平台重试通知的总时长
+const DefaultNotificationTTL = 24 * time.Hour
+
+// NotificationStore 通知处理记录存储
+// NotificationHandler 在业务处理成功后记录通知ID，重复投递的通知直接应答成功而不再调用业务逻辑
+// 多实例部署时应使用共享存储(如 Redis)实现，否则只能在单个实例内去重
+type NotificationStore interface {
+	// Processed 返回通知是否已经处理成功
+	Processed(id string) (bool, error)
+	// MarkProcessed 记录通知已处理成功，记录至少保留 ttl
+	MarkProcessed(id string, ttl time.Duration) error
+}
+
+// NotificationHandler 异步通知处理器
+// 实现 http.Handler，依次完成参数解析、验签、去重、调用业务逻辑和应答
+//
+// 处理逻辑:
+//  1. 解析表单参数，验签失败时返回 400，平台不会重试伪造的通知
+//  2. 通知已处理过时直接应答成功
+//  3. 调用业务逻辑，失败时返回 500 且不记录，由平台重试投递
+//  4. 记录通知已处理并应答成功
+//
+// 注意:
+//   - 并发到达的同一通知可能都会调用业务逻辑，业务逻辑本身仍需保证幂等(如按订单状态更新)
+//   - 同时配置了 Config.NonceCache 时，通知在验签时即被记录，业务失败后平台的重试会被当作重复投递，
+//     使用 NotificationHandler 时通常不需要再配置 NonceCache
+//
+// 示例:
+//
+//	handler := sdk.NewNotificationHandler(client, func(ctx context.Context, params map[string]string) error {
+//	    return orders.MarkPaid(ctx, params["orderNo"])
+//	})
+//	handler.IDParam = "notifyId"
+//	http.Handle("/notify/pay", handler)
+type NotificationHandler struct {
+	// IDParam 通知ID所在的回调参数名，为空或参数缺失时以回调参数的签名摘要作为通知ID
+	IDParam string
+	// Store 处理记录存储，默认为进程内存储
+	Store NotificationStore
+	// TTL 处理记录的保留时间，默认 DefaultNotificationTTL
+	TTL time.Duration
+	// Ack 处理成功和重复投递时的应答，默认 AckSuccess
+	Ack AckResponder
+
+	client *Client
+	handle func(ctx context.Context, params map[string]string) error
+}
+
+// NewNotificationHandler 创建异步通知处理器
+//
+// 参数:
+//   - client: 用于验签的客户端
+//   - handle: 业务处理函数，params 为已验签的回调参数(不包含 sign)，返回错误时平台会重试投递
+//
+// 返回:
+//   - *NotificationHandler: 使用进程内存储和 AckSuccess 应答的处理器，可在注册路由前修改字段
+func NewNotificationHandler(client *Client, handle func(ctx context.Context, params map[string]string) error) *NotificationHandler {
+	return &NotificationHandler{
+		Store:  NewMemoryNotificationStore(),
+		TTL:    DefaultNotificationTTL,
+		Ack:    AckSuccess,
+		client: client,
+		handle: handle,
+	}
+}
+
+// ServeHTTP 处理平台的异步通知请求
+func (h *NotificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "fail", http.StatusBadRequest)
+		return
+	}
+	params, signature, err := splitSignedValues(r.Form)
+	if err != nil {
+		http.Error(w, "fail", http.StatusBadRequest)
+		return
+	}
+	if err := h.client.VerifyCallbackWithHeaders(params, r.Header, signature); err != nil {
+		if errors.Is(err, ErrDuplicateNotification) {
+			h.Ack.Write(w)
+			return
+		}
+		http.Error(w, "fail", http.StatusBadRequest)
+		return
+	}
+
+	// 同一签名的不同 Base64 写法都能通过验签，默认ID取签名摘要而不是签名文本
+	id := notificationNonce(params)
+	if h.IDParam != "" && params[h.IDParam] != "" {
+		id = params[h.IDParam]
+	}
+
+	processed, err := h.Store.Processed(id)
+	if err != nil {
+		// 无法确认是否处理过时不调用业务逻辑，由平台稍后重试
+		http.Error(w, "fail", http.StatusInternalServerError)
+		return
+	}
+	if processed {
+		h.Ack.Write(w)
+		return
+	}
+
+	if err := h.handle(r.Context(), params); err != nil {
+		http.Error(w, "fail", http.StatusInternalServerError)
+		return
+	}
+
+	// 业务已处理成功，记录失败时仍然应答成功，避免平台重复投递
+	ttl := h.TTL
+	if ttl <= 0 {
+		ttl = DefaultNotificationTTL
+	}
+	if err := h.Store.MarkProcessed(id, ttl); err != nil {
+		log.Printf("[SDK Warning] failed to record processed notification %s: %v", id, err)
+	}
+	h.Ack.Write(w)
+}
+
+// memoryNotificationStore 进程内通知处理记录存储
+type memoryNotificationStore struct {
+	mu        sync.Mutex
+	expiresAt map[string]time.Time
+	// nextPrune 记录数达到该值时清理过期记录，清理后翻倍，使清理的开销均摊到每次写入
+	nextPrune int
+}
+
+// NewMemoryNotificationStore 创建进程内通知处理记录存储
+// 记录保存在内存中，进程重启后丢失，适用于单实例部署
+func NewMemoryNotificationStore() NotificationStore {
+	return &memoryNotificationStore{
+		expiresAt: make(map[string]time.Time),
+		nextPrune: 1024,
+	}
+}
+
+func (s *memoryNotificationStore) Processed(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.expiresAt[id]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (s *memoryNotificationStore) MarkProcessed(id string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.expiresAt[id] = now.Add(ttl)
+	if len(s.expiresAt) >= s.nextPrune {
+		for key, expiresAt := range s.expiresAt {
+			if !now.Before(expiresAt) {
+				delete(s.expiresAt, key)
+			}
+		}
+		s.nextPrune = 2 * len(s.expiresAt)
+		if s.nextPrune < 1024 {
+			s.nextPrune = 1024
+		}
+	}
+	return nil
+}
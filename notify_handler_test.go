@@ -0,0 +1,73 @@
+package haozpay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// postNotification 以表单形式向处理器投递一次通知
+func postNotification(handler http.Handler, params map[string]string, signature string) *httptest.ResponseRecorder {
+	form := url.Values{"sign": {signature}}
+	for k, v := range params {
+		form.Set(k, v)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+// TestNotificationHandlerDedupesReencodedSignature 未配置 IDParam 时，
+// 换一种 Base64 写法重新投递的通知应直接应答成功，不再调用业务逻辑
+func TestNotificationHandlerDedupesReencodedSignature(t *testing.T) {
+	params := map[string]string{"merchantNo": "M1", "orderNo": "ORDER001", "payStatus": "SUCCESS"}
+	signature := signCallback(t, params)
+
+	for name, redelivery := range reencodings(t, signature) {
+		t.Run(name, func(t *testing.T) {
+			client := newTestClient(t, okHandler)
+			calls := 0
+			handler := NewNotificationHandler(client, func(ctx context.Context, params map[string]string) error {
+				calls++
+				return nil
+			})
+
+			for i, sig := range []string{signature, redelivery} {
+				w := postNotification(handler, params, sig)
+				if w.Code != http.StatusOK || w.Body.String() != AckSuccess.Body {
+					t.Fatalf("delivery %d: status %d, body %q", i+1, w.Code, w.Body.String())
+				}
+			}
+			if calls != 1 {
+				t.Fatalf("handler called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+// TestNotificationHandlerIDParam 配置了 IDParam 时以该参数去重
+func TestNotificationHandlerIDParam(t *testing.T) {
+	client := newTestClient(t, okHandler)
+	calls := 0
+	handler := NewNotificationHandler(client, func(ctx context.Context, params map[string]string) error {
+		calls++
+		return nil
+	})
+	handler.IDParam = "notifyId"
+
+	// 通知ID相同而内容不同的两次投递视为同一通知
+	for _, status := range []string{"PAYING", "SUCCESS"} {
+		params := map[string]string{"merchantNo": "M1", "notifyId": "N1", "payStatus": status}
+		if w := postNotification(handler, params, signCallback(t, params)); w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", status, w.Code)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+}
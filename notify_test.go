@@ -0,0 +1,88 @@
+package haozpay
+
+import (
+	"encoding/base64"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapNonceCache 测试用的进程内 NonceCache
+type mapNonceCache struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (c *mapNonceCache) CheckAndStore(nonce string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.seen == nil {
+		c.seen = make(map[string]bool)
+	}
+	seen := c.seen[nonce]
+	c.seen[nonce] = true
+	return seen, nil
+}
+
+// signCallback 使用测试密钥为回调参数签名，返回标准 Base64 编码的签名
+func signCallback(t *testing.T, params map[string]string) string {
+	t.Helper()
+	key, _, _ := testKeyPair(t)
+	fields := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		fields[k] = v
+	}
+	signature, err := generateSignWithKey(fields, key)
+	if err != nil {
+		t.Fatalf("generateSignWithKey: %v", err)
+	}
+	return signature
+}
+
+// reencodings 返回同一签名的其他 Base64 写法，都能通过验签
+func reencodings(t *testing.T, signature string) map[string]string {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		t.Fatalf("decode signature: %v", err)
+	}
+	return map[string]string{
+		"url":     base64.URLEncoding.EncodeToString(raw),
+		"raw std": base64.RawStdEncoding.EncodeToString(raw),
+		"raw url": base64.RawURLEncoding.EncodeToString(raw),
+	}
+}
+
+// TestVerifyCallbackRejectsReencodedReplay 换一种 Base64 写法重放的回调仍应被识别为重复投递
+func TestVerifyCallbackRejectsReencodedReplay(t *testing.T) {
+	params := map[string]string{"merchantNo": "M1", "orderNo": "ORDER001", "payStatus": "SUCCESS"}
+	signature := signCallback(t, params)
+
+	for name, replay := range reencodings(t, signature) {
+		t.Run(name, func(t *testing.T) {
+			client := newTestClient(t, okHandler, func(cfg *Config) {
+				cfg.WithNonceCache(&mapNonceCache{})
+			})
+			if err := client.VerifyCallback(params, signature); err != nil {
+				t.Fatalf("first delivery: %v", err)
+			}
+			if err := client.VerifyCallback(params, replay); !errors.Is(err, ErrDuplicateNotification) {
+				t.Fatalf("replay %q: err = %v, want ErrDuplicateNotification", replay, err)
+			}
+		})
+	}
+}
+
+// TestVerifyCallbackNonceDistinguishesNotifications 内容不同的通知不应被当作重复投递
+func TestVerifyCallbackNonceDistinguishesNotifications(t *testing.T) {
+	client := newTestClient(t, okHandler, func(cfg *Config) {
+		cfg.WithNonceCache(&mapNonceCache{})
+	})
+	for _, orderNo := range []string{"ORDER001", "ORDER002"} {
+		params := map[string]string{"merchantNo": "M1", "orderNo": orderNo}
+		if err := client.VerifyCallback(params, signCallback(t, params)); err != nil {
+			t.Fatalf("%s: %v", orderNo, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package haozpay
+
+import "net/http"
+
+// Operation SDK 提供的业务操作
+// 用于在错误信息等场景中标识请求所属的业务接口
+type Operation int
+
+const (
+	// OperationUnknown 未知操作
+	OperationUnknown Operation = iota
+	// OperationCreateOrder 统一下单
+	OperationCreateOrder
+	// OperationCancelOrder 订单取消
+	OperationCancelOrder
+	// OperationQueryOrder 订单查询
+	OperationQueryOrder
+	// OperationCreateRefund 退款
+	OperationCreateRefund
+	// OperationCreateBatchRefund 批量退款
+	OperationCreateBatchRefund
+	// OperationQueryRefund 退款查询
+	OperationQueryRefund
+	// OperationQueryRefundList 退款列表查询
+	OperationQueryRefundList
+	// OperationPing 连通性检查
+	OperationPing
+	// OperationDoSignedRequest 调用 SDK 尚未封装的接口
+	OperationDoSignedRequest
+)
+
+// operationNames 操作名称
+var operationNames = map[Operation]string{
+	OperationUnknown:           "Unknown",
+	OperationCreateOrder:       "CreateOrder",
+	OperationCancelOrder:       "CancelOrder",
+	OperationQueryOrder:        "QueryOrder",
+	OperationCreateRefund:      "CreateRefund",
+	OperationCreateBatchRefund: "CreateBatchRefund",
+	OperationQueryRefund:       "QueryRefund",
+	OperationQueryRefundList:   "QueryRefundList",
+	OperationPing:              "Ping",
+	OperationDoSignedRequest:   "DoSignedRequest",
+}
+
+// String 返回操作名称，与 SDK 中对应的方法名一致
+func (o Operation) String() string {
+	if name, ok := operationNames[o]; ok {
+		return name
+	}
+	return operationNames[OperationUnknown]
+}
+
+// endpoint 接口定义
+type endpoint struct {
+	// method HTTP 请求方法
+	method string
+	// path 默认接口路径
+	path string
+}
+
+// operationEndpoints 各操作对应的接口
+// Ping 没有独立接口，使用退款列表查询的路径
+// 订单查询接口的路径未在平台文档中公布，没有默认路径，需要通过 Config.WithEndpointPath 显式配置
+// 新增 GET 接口时在这里声明请求方法即可，请求参数由 doRequest 改为查询参数发送
+var operationEndpoints = map[Operation]endpoint{
+	OperationCreateOrder:       {method: http.MethodPost, path: "/pay-core/payment/order"},
+	OperationCancelOrder:       {method: http.MethodPost, path: "/pay-core/payment/cancel"},
+	OperationQueryOrder:        {method: http.MethodPost},
+	OperationCreateRefund:      {method: http.MethodPost, path: "/pay-core/payment/refund"},
+	OperationCreateBatchRefund: {method: http.MethodPost, path: "/pay-core/payment/refund/batch"},
+	OperationQueryRefund:       {method: http.MethodPost, path: "/pay-core/payment/refund/query"},
+	OperationQueryRefundList:   {method: http.MethodPost, path: "/pay-core/payment/refund/list"},
+}
+
+// DefaultPath 返回操作默认的接口路径，没有对应接口或没有默认路径的操作(如 QueryOrder)返回空字符串
+func (o Operation) DefaultPath() string {
+	return operationEndpoints[o].path
+}
+
+// hasEndpoint 判断操作是否对应网关接口，即是否可以通过 EndpointPaths 设置路径
+func (o Operation) hasEndpoint() bool {
+	_, ok := operationEndpoints[o]
+	return ok
+}
+
+// method 返回操作的 HTTP 请求方法，没有对应接口的操作(如 Ping、DoSignedRequest)使用 POST
+func (o Operation) method() string {
+	if method := operationEndpoints[o].method; method != "" {
+		return method
+	}
+	return http.MethodPost
+}
@@ -0,0 +1,54 @@
+package haozpay
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestOperationString 每个操作都有名称，未知的值返回 Unknown
+func TestOperationString(t *testing.T) {
+	tests := []struct {
+		op   Operation
+		want string
+	}{
+		{OperationUnknown, "Unknown"},
+		{OperationCreateOrder, "CreateOrder"},
+		{OperationCancelOrder, "CancelOrder"},
+		{OperationQueryOrder, "QueryOrder"},
+		{OperationCreateRefund, "CreateRefund"},
+		{OperationCreateBatchRefund, "CreateBatchRefund"},
+		{OperationQueryRefund, "QueryRefund"},
+		{OperationQueryRefundList, "QueryRefundList"},
+		{OperationPing, "Ping"},
+		{OperationDoSignedRequest, "DoSignedRequest"},
+		{OperationDoSignedRequest + 1, "Unknown"},
+		{Operation(-1), "Unknown"},
+		{Operation(1 << 20), "Unknown"},
+	}
+	for _, tt := range tests {
+		if got := tt.op.String(); got != tt.want {
+			t.Errorf("Operation(%d).String() = %q, want %q", int(tt.op), got, tt.want)
+		}
+	}
+
+	// 新增的操作都应在上表中
+	if len(operationNames) != int(OperationDoSignedRequest)+1 {
+		t.Errorf("operationNames has %d entries, want %d: update this test for new operations", len(operationNames), int(OperationDoSignedRequest)+1)
+	}
+}
+
+// TestOperationNamesMatchMethods 操作名称与 SDK 中对应的方法名一致
+func TestOperationNamesMatchMethods(t *testing.T) {
+	payment := reflect.TypeOf(&PaymentService{})
+	client := reflect.TypeOf(&Client{})
+	for op := OperationCreateOrder; op <= OperationDoSignedRequest; op++ {
+		name := op.String()
+		if _, ok := payment.MethodByName(name); ok {
+			continue
+		}
+		if _, ok := client.MethodByName(name); ok {
+			continue
+		}
+		t.Errorf("%s has no matching method on PaymentService or Client", name)
+	}
+}
@@ -0,0 +1,96 @@
+package haozpay
+
+import (
+	"context"
+	"sync"
+)
+
+// OrderResult 批量查询中单个订单的查询结果
+type OrderResult struct {
+	// OrderNo 查询的订单号
+	OrderNo string
+	// Order 查询成功时的订单信息
+	Order *PaymentOrderResponse
+	// Err 查询失败的原因，成功时为 nil；ctx 取消后未发出的查询为 ctx.Err()
+	Err error
+}
+
+// QueryOrdersConcurrent 并发查询多个订单
+// 使用最多 concurrency 个 goroutine 调用 QueryOrderByOrderNo，结果按 orderNos 的顺序返回，
+// 单个订单查询失败不影响其他订单，失败原因记录在对应结果的 Err 中
+//
+// 参数:
+//   - ctx: 上下文，取消后不再发出新的查询，进行中的查询随之取消
+//   - orderNos: 订单号列表
+//   - concurrency: 最大并发数，必须大于 0
+//   - opts: 单次请求选项，对每个订单的查询生效
+//
+// 返回:
+//   - []OrderResult: 与 orderNos 一一对应的查询结果，ctx 取消时同样返回已完成的结果
+//   - error: concurrency 不合法时返回 ErrInvalidRequest，ctx 取消时返回 ctx.Err()，否则为 nil
+//
+// 注意:
+//   - 配置了 WithRateLimit 时并发查询同样受客户端限流约束
+//   - 配置了 WithResponseCache 时结果可能来自缓存，需要最新状态时传入 WithNoCache()
+//
+// 示例:
+//
+//	results, err := client.Payment.QueryOrdersConcurrent(ctx, orderNos, 8)
+//	for _, r := range results {
+//	    if r.Err != nil {
+//	        log.Printf("订单 %s 查询失败: %v", r.OrderNo, r.Err)
+//	        continue
+//	    }
+//	    refresh(r.Order)
+//	}
+func (s *PaymentService) QueryOrdersConcurrent(ctx context.Context, orderNos []string, concurrency int, opts ...RequestOption) ([]OrderResult, error) {
+	if concurrency <= 0 {
+		return nil, &SDKError{
+			Code:       ErrInvalidRequest.Code,
+			Message:    "concurrency must be greater than 0",
+			StatusCode: 0,
+			Operation:  OperationQueryOrder,
+		}
+	}
+	ctx = contextOrBackground(ctx)
+
+	results := make([]OrderResult, len(orderNos))
+	for i, orderNo := range orderNos {
+		results[i].OrderNo = orderNo
+	}
+	if concurrency > len(orderNos) {
+		concurrency = len(orderNos)
+	}
+
+	// 每个 worker 只写入自己取到的下标，结果切片不需要加锁
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i].Order, results[i].Err = s.QueryOrderByOrderNo(ctx, orderNos[i], opts...)
+			}
+		}()
+	}
+
+dispatch:
+	for i := range orderNos {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for j := i; j < len(orderNos); j++ {
+				results[j].Err = ctx.Err()
+			}
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
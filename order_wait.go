@@ -0,0 +1,140 @@
+package haozpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// OrderWaitOptions WaitForOrderPaid 的轮询参数
+type OrderWaitOptions struct {
+	// IsTerminal 判断订单是否已到达终态(已支付、已关闭、支付失败等)，必填
+	// 不同支付方式的订单状态码由平台约定，SDK 不做假设
+	IsTerminal func(order *PaymentOrderResponse) bool
+	// Interval 首次轮询间隔，默认 2 秒
+	Interval time.Duration
+	// MaxInterval 最大轮询间隔，轮询间隔每次翻倍直到该值，默认 10 秒
+	MaxInterval time.Duration
+	// Timeout 轮询的总超时时间，默认 0 表示只受 ctx 控制
+	Timeout time.Duration
+}
+
+// 轮询参数默认值
+const (
+	defaultOrderWaitInterval    = 2 * time.Second
+	defaultOrderWaitMaxInterval = 10 * time.Second
+)
+
+// WaitForOrderPaid 轮询订单直到到达终态
+// 适用于扫码支付等下单后需要等待用户完成支付的场景，
+// 轮询间隔从 Interval 开始逐次翻倍，不超过 MaxInterval
+//
+// 参数:
+//   - ctx: 上下文，取消或超时时停止轮询
+//   - orderNo: 平台订单号
+//   - opts: 轮询参数，IsTerminal 必填
+//
+// 返回:
+//   - *PaymentOrderResponse: 最后一次查询到的订单，超时时可能为 nil
+//   - int: 订单终态，即 OrderStatus
+//   - error: 超时时返回 ErrTimeout，查询返回非临时性错误时直接返回该错误
+//
+// 注意:
+//   - 使用 QueryOrder 查询，需要先配置订单查询接口的路径(见 QueryOrder)
+//   - 每次查询都绕过查询缓存
+//   - 网络错误和网关 5xx 错误视为临时性错误，继续轮询
+//
+// 示例:
+//
+//	order, status, err := client.Payment.WaitForOrderPaid(ctx, orderNo, &sdk.OrderWaitOptions{
+//	    IsTerminal: func(o *sdk.PaymentOrderResponse) bool { return o.OrderStatus != orderStatusPaying },
+//	    Timeout:    3 * time.Minute,
+//	})
+func (s *PaymentService) WaitForOrderPaid(ctx context.Context, orderNo string, opts *OrderWaitOptions, reqOpts ...RequestOption) (*PaymentOrderResponse, int, error) {
+	ctx = contextOrBackground(ctx)
+	if opts == nil || opts.IsTerminal == nil {
+		return nil, 0, &SDKError{
+			Code:       ErrInvalidRequest.Code,
+			Message:    "OrderWaitOptions.IsTerminal is required",
+			StatusCode: 0,
+			Operation:  OperationQueryOrder,
+		}
+	}
+
+	interval, maxInterval := waitIntervals(opts.Interval, opts.MaxInterval)
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	// 轮询需要每次拿到网关的最新状态，缓存的结果会让订单在 TTL 内看起来停留在旧状态
+	queryOpts := append([]RequestOption{WithNoCache()}, reqOpts...)
+
+	var last *PaymentOrderResponse
+	for {
+		order, err := s.QueryOrderByOrderNo(ctx, orderNo, queryOpts...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, 0, waitTimeoutError(orderNo, ctx.Err())
+			}
+			if !isTransientError(err) {
+				return last, 0, err
+			}
+		} else {
+			last = order
+			if opts.IsTerminal(order) {
+				return order, order.OrderStatus, nil
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return last, 0, waitTimeoutError(orderNo, ctx.Err())
+		}
+
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// waitIntervals 补齐轮询间隔的默认值，保证最大间隔不小于首次间隔
+func waitIntervals(interval, maxInterval time.Duration) (time.Duration, time.Duration) {
+	if interval <= 0 {
+		interval = defaultOrderWaitInterval
+	}
+	if maxInterval <= 0 {
+		maxInterval = defaultOrderWaitMaxInterval
+	}
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	return interval, maxInterval
+}
+
+// waitTimeoutError 轮询超时错误
+func waitTimeoutError(orderNo string, cause error) *SDKError {
+	return &SDKError{
+		Code:       ErrTimeout.Code,
+		Message:    fmt.Sprintf("order %s did not reach a terminal state: %v", orderNo, cause),
+		StatusCode: 0,
+		Operation:  OperationQueryOrder,
+	}
+}
+
+// isTransientError 判断错误是否为可以稍后重试的临时性错误
+func isTransientError(err error) bool {
+	var sdkErr *SDKError
+	if !errors.As(err, &sdkErr) {
+		return false
+	}
+	return sdkErr.Code == ErrNetworkError.Code || sdkErr.StatusCode >= http.StatusInternalServerError
+}
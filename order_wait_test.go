@@ -0,0 +1,42 @@
+package haozpay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWaitForOrderPaidBypassesCache 开启响应缓存时，轮询的每次查询都应到达网关
+func TestWaitForOrderPaidBypassesCache(t *testing.T) {
+	var queries int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		status := 0
+		if atomic.AddInt32(&queries, 1) >= 3 {
+			status = 1
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"code":0,"message":"ok","data":{"seqId":"S1","orderStatus":%d}}`, status)
+	}, func(cfg *Config) {
+		cfg.WithResponseCache(time.Minute, NewMemoryResponseCache()).
+			WithEndpointPath(OperationQueryOrder, "/pay-core/payment/order/query")
+	})
+
+	order, status, err := client.Payment.WaitForOrderPaid(context.Background(), "ORDER001", &OrderWaitOptions{
+		IsTerminal:  func(o *PaymentOrderResponse) bool { return o.OrderStatus == 1 },
+		Interval:    time.Millisecond,
+		MaxInterval: time.Millisecond,
+		Timeout:     5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("WaitForOrderPaid: %v", err)
+	}
+	if status != 1 || order == nil || order.OrderStatus != 1 {
+		t.Fatalf("WaitForOrderPaid = %+v, %d, want status 1", order, status)
+	}
+	if got := atomic.LoadInt32(&queries); got != 3 {
+		t.Fatalf("gateway queries = %d, want 3", got)
+	}
+}
@@ -0,0 +1,106 @@
+package haozpay
+
+import (
+	"context"
+	"fmt"
+)
+
+// IterateRefunds 自动翻页遍历退款列表
+// 从 req.PageNum(为 0 时从第 1 页)开始逐页查询，对每条退款记录调用 fn，
+// 网关返回的 Total 大于 0 时，已取得的记录数达到 Total 即结束；
+// 未返回 Total(或为 0)时继续翻页，直到返回空页或记录数少于每页条数的页
+//
+// 参数:
+//   - ctx: 上下文
+//   - req: 查询参数，StartDate 和 EndDate 必填，不会被修改
+//   - fn: 每条退款记录的回调函数，返回错误时停止遍历并原样返回该错误
+//   - opts: 单次请求选项，对每一页的查询生效
+//
+// 返回:
+//   - error: 查询失败、fn 返回的错误，或网关分页异常时返回的错误
+//
+// 注意:
+//   - 网关忽略页码、重复返回同一页时会无限循环，检测到返回的页码与请求不符或内容与上一页相同时返回错误
+//   - 遍历期间有新的退款产生时，记录可能跨页重复或遗漏，需要精确对账时应缩小日期范围
+//
+// 示例:
+//
+//	err := client.Payment.IterateRefunds(ctx, req, func(refund *sdk.QueryRefundResponse) error {
+//	    return ledger.Record(refund)
+//	})
+func (s *PaymentService) IterateRefunds(ctx context.Context, req *QueryRefundListRequest, fn func(*QueryRefundResponse) error, opts ...RequestOption) error {
+	if req == nil {
+		return &SDKError{
+			Code:       ErrInvalidRequest.Code,
+			Message:    "refund list request cannot be nil",
+			StatusCode: 0,
+			Operation:  OperationQueryRefundList,
+		}
+	}
+
+	pageReq := *req
+	if pageReq.PageNum <= 0 {
+		pageReq.PageNum = 1
+	}
+
+	// 从中间页开始时，之前的页计入已取得的记录数
+	fetched := int64(pageReq.PageNum-1) * int64(pageReq.PageSize)
+	var previousFirst string
+	for {
+		page, err := s.QueryRefundList(ctx, &pageReq, opts...)
+		if err != nil {
+			return err
+		}
+		if len(page.List) == 0 {
+			return nil
+		}
+
+		// 防止网关忽略页码时反复返回同一页
+		if page.PageNum != 0 && page.PageNum != pageReq.PageNum {
+			return paginationError(fmt.Sprintf("requested page %d but gateway returned page %d", pageReq.PageNum, page.PageNum))
+		}
+		first := pageFingerprint(page.List[0])
+		if first != "" && first == previousFirst {
+			return paginationError(fmt.Sprintf("page %d repeats the previous page", pageReq.PageNum))
+		}
+		previousFirst = first
+
+		for _, refund := range page.List {
+			if err := fn(refund); err != nil {
+				return err
+			}
+		}
+
+		fetched += int64(len(page.List))
+		if page.Total > 0 && fetched >= page.Total {
+			return nil
+		}
+		// 请求未指定每页条数时使用网关返回的每页条数，两者都为 0 时只能以空页判断结束
+		pageSize := pageReq.PageSize
+		if pageSize <= 0 {
+			pageSize = page.PageSize
+		}
+		if pageSize > 0 && len(page.List) < pageSize {
+			return nil
+		}
+		pageReq.PageNum++
+	}
+}
+
+// pageFingerprint 返回用于判断两页是否相同的标识，取页内第一条记录的退款流水号
+func pageFingerprint(refund *QueryRefundResponse) string {
+	if refund == nil {
+		return ""
+	}
+	return refund.RefundSeqId
+}
+
+// paginationError 网关分页异常错误
+func paginationError(message string) *SDKError {
+	return &SDKError{
+		Code:       ErrInvalidResponse.Code,
+		Message:    "pagination stopped: " + message,
+		StatusCode: 0,
+		Operation:  OperationQueryRefundList,
+	}
+}
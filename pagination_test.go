@@ -0,0 +1,88 @@
+package haozpay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// refundListGateway 按页返回退款列表的测试网关
+type refundListGateway struct {
+	mu sync.Mutex
+	// pages 按页码(从 1 开始)返回的记录数，超出范围的页返回空列表
+	pages []int
+	// total 响应中的 total 字段，为 0 时不返回该字段
+	total int64
+	// pageSize 响应中的 pageSize 字段
+	pageSize int
+	requests int
+}
+
+func (g *refundListGateway) handle(w http.ResponseWriter, r *http.Request) {
+	var sent HaozPayRequest
+	var biz QueryRefundListRequest
+	if err := json.NewDecoder(r.Body).Decode(&sent); err == nil {
+		_ = json.Unmarshal([]byte(sent.BizBody), &biz)
+	}
+
+	g.mu.Lock()
+	g.requests++
+	g.mu.Unlock()
+
+	data := map[string]interface{}{"pageNum": biz.PageNum, "pageSize": g.pageSize}
+	if g.total > 0 {
+		data["total"] = g.total
+	}
+	list := []map[string]interface{}{}
+	if biz.PageNum >= 1 && biz.PageNum <= len(g.pages) {
+		for i := 0; i < g.pages[biz.PageNum-1]; i++ {
+			list = append(list, map[string]interface{}{"refundSeqId": fmt.Sprintf("R%d-%d", biz.PageNum, i)})
+		}
+	}
+	data["list"] = list
+
+	body, _ := json.Marshal(map[string]interface{}{"code": 0, "message": "ok", "data": data})
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// TestIterateRefundsStopConditions Total 大于 0 时按 Total 结束，否则翻页到空页或不满一页的页
+func TestIterateRefundsStopConditions(t *testing.T) {
+	tests := []struct {
+		name        string
+		gw          *refundListGateway
+		reqPageSize int
+		want        int
+		wantReqs    int
+	}{
+		{name: "total reached", gw: &refundListGateway{pages: []int{2, 2, 1}, total: 5}, reqPageSize: 2, want: 5, wantReqs: 3},
+		{name: "missing total, short last page", gw: &refundListGateway{pages: []int{2, 2, 1}}, reqPageSize: 2, want: 5, wantReqs: 3},
+		{name: "missing total, full last page", gw: &refundListGateway{pages: []int{2, 2}}, reqPageSize: 2, want: 4, wantReqs: 3},
+		{name: "missing total, page size from response", gw: &refundListGateway{pages: []int{3, 1}, pageSize: 3}, want: 4, wantReqs: 2},
+		{name: "missing total and page size", gw: &refundListGateway{pages: []int{3, 1}}, want: 4, wantReqs: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, tt.gw.handle)
+			req := &QueryRefundListRequest{StartDate: "2024-01-01", EndDate: "2024-01-31", PageSize: tt.reqPageSize}
+
+			got := 0
+			err := client.Payment.IterateRefunds(context.Background(), req, func(*QueryRefundResponse) error {
+				got++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("IterateRefunds: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("visited %d refunds, want %d", got, tt.want)
+			}
+			if tt.gw.requests != tt.wantReqs {
+				t.Errorf("gateway received %d requests, want %d", tt.gw.requests, tt.wantReqs)
+			}
+		})
+	}
+}
@@ -3,218 +3,615 @@ package haozpay
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
+// PaymentService 支付服务，提供下单、查询、退款等接口
+// 各方法的 ctx 可以传入 nil，此时使用 context.Background()，便于脚本等无需超时控制的简单场景
 type PaymentService struct {
 	client *resty.Client
 	config *Config
+	// cache 查询接口的响应缓存，未开启缓存时为 nil
+	cache ResponseCache
+	// latency 响应耗时统计，未开启时为 nil
+	latency *latencyRecorder
 }
 
 func NewPaymentService(client *resty.Client, config *Config) *PaymentService {
-	return &PaymentService{
+	s := &PaymentService{
 		client: client,
 		config: config,
+		cache:  config.responseCache(),
 	}
+	if config.CollectLatencyStats {
+		s.latency = newLatencyRecorder()
+	}
+	return s
 }
 
-func (s *PaymentService) CreateOrder(ctx context.Context, req *CreatePaymentOrderRequest) (*PaymentOrderResponse, error) {
-	bizBodyBytes, err := json.Marshal(req)
-	if err != nil {
+func (s *PaymentService) CreateOrder(ctx context.Context, req *CreatePaymentOrderRequest, opts ...RequestOption) (*PaymentOrderResponse, error) {
+	if msg := validateOrderRequest(req); msg != "" {
 		return nil, &SDKError{
-			Code:       ErrInvalidResponse.Code,
-			Message:    fmt.Sprintf("failed to marshal request: %v", err),
+			Code:       ErrInvalidRequest.Code,
+			Message:    msg,
 			StatusCode: 0,
+			Operation:  OperationCreateOrder,
 		}
 	}
 
-	haozReq := &HaozPayRequest{
-		MerchantNo: s.config.MerchantNo,
-		Timestamp:  currentTimestampMillis(),
-		BizBody:    string(bizBodyBytes),
+	var data *PaymentOrderResponse
+	if err := s.sendRequest(ctx, OperationCreateOrder, s.config.endpointPath(OperationCreateOrder), req, &data, opts...); err != nil {
+		return nil, err
 	}
+	return data, nil
+}
 
-	var result struct {
-		Response
-		Data *PaymentOrderResponse `json:"data"`
+// CreateOrderWithMap 使用 map 作为业务参数下单
+// 已持有 map 形式业务参数的调用方可直接传入，不必先转换为 CreatePaymentOrderRequest，
+// map 序列化为 bizBody 后与 CreateOrder 的签名和发送方式相同，签名时参数重新按字典序排列，与 map 的遍历顺序无关
+//
+// 参数:
+//   - ctx: 上下文
+//   - bizBody: 业务参数，字段名与 CreatePaymentOrderRequest 的 JSON 字段名一致，不能为 nil
+//   - opts: 单次请求选项
+//
+// 返回:
+//   - *PaymentOrderResponse: 下单结果
+//   - error: bizBody 为 nil 时返回 ErrInvalidRequest，其他错误与 CreateOrder 相同
+//
+// 注意:
+//   - 不执行 CreateOrder 的本地参数校验(金额、回调地址等)，由网关校验
+//   - 数字建议使用 json.Number 或整数，浮点数按最短十进制文本序列化
+//
+// 示例:
+//
+//	order, err := client.Payment.CreateOrderWithMap(ctx, map[string]interface{}{
+//	    "orderTitle":  "商品",
+//	    "orderAmount": json.Number("0.01"),
+//	    "notifyUrl":   "https://example.com/notify",
+//	})
+func (s *PaymentService) CreateOrderWithMap(ctx context.Context, bizBody map[string]interface{}, opts ...RequestOption) (*PaymentOrderResponse, error) {
+	if bizBody == nil {
+		return nil, &SDKError{
+			Code:       ErrInvalidRequest.Code,
+			Message:    "order bizBody cannot be nil",
+			StatusCode: 0,
+			Operation:  OperationCreateOrder,
+		}
 	}
 
-	_, err = s.client.R().
-		SetContext(ctx).
-		SetBody(haozReq).
-		SetResult(&result).
-		Post("/pay-core/payment/order")
+	var data *PaymentOrderResponse
+	if err := s.doRequest(ctx, OperationCreateOrder, s.config.endpointPath(OperationCreateOrder), bizBody, &data, opts...); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
 
-	if err != nil {
+// validateOrderRequest 校验下单请求，返回校验失败的原因，校验通过时返回空字符串
+func validateOrderRequest(req *CreatePaymentOrderRequest) string {
+	if req == nil {
+		return "order request cannot be nil"
+	}
+	// 必填、金额和回调地址由 validate 标签校验，金额以元为单位，最多保留两位小数
+	return validateStruct(req)
+}
+
+// validateCallbackURL 校验回调地址必须是 http 或 https 的绝对地址
+func validateCallbackURL(field, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Sprintf("%s must be an absolute http or https URL, got %q", field, rawURL)
+	}
+	return ""
+}
+
+func (s *PaymentService) CancelOrder(ctx context.Context, req *CancelPaymentOrderRequest, opts ...RequestOption) error {
+	return s.doRequest(ctx, OperationCancelOrder, s.config.endpointPath(OperationCancelOrder), req, nil, opts...)
+}
+
+// QueryOrder 查询订单
+// 订单查询接口的路径未在平台文档中公布，SDK 不提供默认路径，
+// 需要先通过 Config.WithEndpointPath(OperationQueryOrder, path) 配置平台提供的路径
+//
+// 返回:
+//   - *PaymentOrderResponse: 订单信息
+//   - error: req 为 nil 或 OrderNo 为空时返回 ErrInvalidRequest，未配置接口路径时返回 *ConfigError
+func (s *PaymentService) QueryOrder(ctx context.Context, req *QueryOrderRequest, opts ...RequestOption) (*PaymentOrderResponse, error) {
+	if req == nil {
 		return nil, &SDKError{
-			Code:       ErrNetworkError.Code,
-			Message:    fmt.Sprintf("failed to create payment order: %v", err),
+			Code:       ErrInvalidRequest.Code,
+			Message:    "order query request cannot be nil",
 			StatusCode: 0,
+			Operation:  OperationQueryOrder,
 		}
 	}
 
-	if result.Code != 0 {
-		return nil, NewSDKErrorWithRequestID(
-			result.Code,
-			result.Message,
-			0,
-			result.RequestID,
-		)
+	path := s.config.endpointPath(OperationQueryOrder)
+	if path == "" {
+		return nil, ErrInvalidConfig("QueryOrder endpoint path is not configured, set it with WithEndpointPath(OperationQueryOrder, path)")
 	}
 
-	return result.Data, nil
+	var data *PaymentOrderResponse
+	if err := s.doRequest(ctx, OperationQueryOrder, path, req, &data, opts...); err != nil {
+		return nil, err
+	}
+	return data, nil
 }
 
-func (s *PaymentService) CancelOrder(ctx context.Context, req *CancelPaymentOrderRequest) error {
-	bizBodyBytes, err := json.Marshal(req)
-	if err != nil {
-		return &SDKError{
-			Code:       ErrInvalidResponse.Code,
-			Message:    fmt.Sprintf("failed to marshal request: %v", err),
+// QueryOrderByOrderNo 按订单号查询订单
+func (s *PaymentService) QueryOrderByOrderNo(ctx context.Context, orderNo string, opts ...RequestOption) (*PaymentOrderResponse, error) {
+	return s.QueryOrder(ctx, &QueryOrderRequest{OrderNo: orderNo}, opts...)
+}
+
+func (s *PaymentService) CreateRefund(ctx context.Context, req *CreateRefundRequest, opts ...RequestOption) (*RefundResponse, error) {
+	if msg := validateRefundRequest(req); msg != "" {
+		return nil, &SDKError{
+			Code:       ErrInvalidRequest.Code,
+			Message:    msg,
 			StatusCode: 0,
+			Operation:  OperationCreateRefund,
 		}
 	}
 
-	haozReq := &HaozPayRequest{
-		MerchantNo: s.config.MerchantNo,
-		Timestamp:  currentTimestampMillis(),
-		BizBody:    string(bizBodyBytes),
+	if s.config.RefundPrecheck {
+		// 试运行不发送退款请求，也不需要查询
+		if options := newRequestOptions(opts); !options.dryRun && !s.config.DryRun {
+			if err := s.checkRefundable(ctx, req, options); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	var result Response
-
-	_, err = s.client.R().
-		SetContext(ctx).
-		SetBody(haozReq).
-		SetResult(&result).
-		Post("/pay-core/payment/cancel")
+	var data *RefundResponse
+	if err := s.sendRequest(ctx, OperationCreateRefund, s.config.endpointPath(OperationCreateRefund), req, &data, opts...); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
 
-	if err != nil {
-		return &SDKError{
-			Code:       ErrNetworkError.Code,
-			Message:    fmt.Sprintf("failed to cancel payment order: %v", err),
+// CreateBatchRefund 批量退款
+// 发送请求前逐条校验退款明细，存在校验失败的明细时不发送请求，
+// 返回 *BatchValidationError，其中列出所有校验失败的明细下标和原因；
+// 网关受理后，每条明细的处理结果在 BatchRefundResponse.Results 中单独返回，部分失败不影响其他明细
+func (s *PaymentService) CreateBatchRefund(ctx context.Context, req *BatchRefundRequest, opts ...RequestOption) (*BatchRefundResponse, error) {
+	if msg := validateStruct(req); msg != "" {
+		return nil, &SDKError{
+			Code:       ErrInvalidRequest.Code,
+			Message:    msg,
 			StatusCode: 0,
+			Operation:  OperationCreateBatchRefund,
 		}
 	}
 
-	if result.Code != 0 {
-		return NewSDKErrorWithRequestID(
-			result.Code,
-			result.Message,
-			0,
-			result.RequestID,
-		)
+	validationErr := &BatchValidationError{}
+	for i, item := range req.Items {
+		if msg := validateRefundRequest(item); msg != "" {
+			validationErr.Indices = append(validationErr.Indices, i)
+			validationErr.Messages = append(validationErr.Messages, msg)
+		}
+	}
+	if len(validationErr.Indices) > 0 {
+		return nil, validationErr
 	}
 
-	return nil
+	var data *BatchRefundResponse
+	if err := s.sendRequest(ctx, OperationCreateBatchRefund, s.config.endpointPath(OperationCreateBatchRefund), req, &data, opts...); err != nil {
+		return nil, err
+	}
+	return data, nil
 }
 
-func (s *PaymentService) CreateRefund(ctx context.Context, req *CreateRefundRequest) (*RefundResponse, error) {
+// validateRefundRequest 校验退款请求，返回校验失败的原因，校验通过时返回空字符串
+func validateRefundRequest(req *CreateRefundRequest) string {
+	if req == nil {
+		return "refund request cannot be nil"
+	}
 	// 业务校验: OrderNo 和 ReqSeqId 不能同时为空
 	if req.OrderNo == "" && req.ReqSeqId == "" {
-		return nil, &SDKError{
+		return "OrderNo and ReqSeqId cannot both be empty, at least one must be provided"
+	}
+	// 批量退款的明细不经过 doRequest 的校验，在这里按 validate 标签校验
+	return validateStruct(req)
+}
+
+func (s *PaymentService) QueryRefund(ctx context.Context, req *QueryRefundRequest, opts ...RequestOption) (*QueryRefundResponse, error) {
+	var data *QueryRefundResponse
+	if err := s.doRequest(ctx, OperationQueryRefund, s.config.endpointPath(OperationQueryRefund), req, &data, opts...); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *PaymentService) QueryRefundList(ctx context.Context, req *QueryRefundListRequest, opts ...RequestOption) (*RefundListResponse, error) {
+	var data *RefundListResponse
+	if err := s.doRequest(ctx, OperationQueryRefundList, s.config.endpointPath(OperationQueryRefundList), req, &data, opts...); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// doRequest 发送皓臻支付业务请求
+// 将业务参数序列化为 bizBody 并封装为 HaozPayRequest 发送，签名由 signatureMiddleware 完成，
+// 收到响应后检查业务状态码
+//
+// 参数:
+//   - op: 业务操作，记录在返回的 SDKError 中
+//   - path: 接口路径
+//   - bizReq: 业务请求参数，序列化后作为 bizBody
+//   - data: 接收响应 data 字段的指针，为 nil 时不解析 data
+//   - opts: 单次请求选项
+func (s *PaymentService) doRequest(ctx context.Context, op Operation, path string, bizReq interface{}, data interface{}, opts ...RequestOption) error {
+	// 签名前按 validate 标签统一校验业务参数
+	if msg := validateStruct(bizReq); msg != "" {
+		return &SDKError{
+			Code:       ErrInvalidRequest.Code,
+			Message:    msg,
+			StatusCode: 0,
+			Operation:  op,
+		}
+	}
+
+	return s.sendRequest(ctx, op, path, bizReq, data, opts...)
+}
+
+// sendRequest 发送已校验的业务请求
+// 调用方已按 validate 标签校验过业务参数时直接使用，避免 doRequest 重复校验
+func (s *PaymentService) sendRequest(ctx context.Context, op Operation, path string, bizReq interface{}, data interface{}, opts ...RequestOption) error {
+	err := s.doRequestOnce(ctx, op, path, bizReq, data, opts...)
+	// 私钥轮换期间，网关拒绝主签名器的签名时使用备用签名器重新发送一次
+	if s.config.FallbackSigner != nil && s.config.isSignatureRejected(err) {
+		return s.doRequestOnce(contextWithFallbackSign(contextOrBackground(ctx)), op, path, bizReq, data, opts...)
+	}
+	return err
+}
+
+// doRequestOnce 使用请求上下文选择的签名器发送一次业务请求
+func (s *PaymentService) doRequestOnce(ctx context.Context, op Operation, path string, bizReq interface{}, data interface{}, opts ...RequestOption) (err error) {
+	ctx = contextOrBackground(ctx)
+	options := newRequestOptions(opts)
+
+	// NewClient 已校验 MerchantNo，这里防止创建客户端后配置被清空，避免网关返回难以理解的错误
+	merchantNo := s.merchantNo(ctx, options)
+	if strings.TrimSpace(merchantNo) == "" {
+		return &SDKError{
 			Code:       ErrInvalidRequest.Code,
-			Message:    "OrderNo and ReqSeqId cannot both be empty, at least one must be provided",
+			Message:    "merchantNo is required, set it with Config.WithMerchantNo",
 			StatusCode: 0,
+			Operation:  op,
 		}
 	}
 
-	bizBodyBytes, err := json.Marshal(req)
+	// 只读查询命中缓存时直接返回，不发送请求
+	var cacheKey string
+	if s.cache != nil && cacheableOperations[op] && data != nil && !options.dryRun && !s.config.DryRun {
+		if _, streaming := data.(responseStreamer); !streaming {
+			cacheKey, _ = responseCacheKey(op, path, merchantNo, bizReq)
+		}
+	}
+	if cacheKey != "" && !options.noCache {
+		if cached, ok := s.cache.Get(cacheKey); ok && json.Unmarshal(cached, data) == nil {
+			// 没有收到网关响应，只标记结果来自缓存，避免调用方读到上一次请求的元信息
+			if options.responseMeta != nil {
+				*options.responseMeta = ResponseMeta{Cached: true}
+			}
+			return nil
+		}
+	}
+
+	// 业务系统的请求ID，与网关返回的 RequestID 一同记录在错误中，便于端到端排查
+	traceID := options.headers[s.config.requestIDHeader()]
+	if traceID == "" {
+		traceID = s.config.requestID(ctx)
+	}
+	annotate := func(e *SDKError) *SDKError {
+		e.Operation = op
+		e.TraceID = traceID
+		return e
+	}
+
+	// 上报调用指标和追踪信息，HTTP 状态码取自实际收到的响应，业务码取自返回的错误
+	ctx, span := s.config.tracer().Start(ctx, path)
+	observer := s.config.metricsObserver()
+	observer.OnRequestStart(ctx, op, path)
+	start := time.Now()
+	var resp *resty.Response
+	var gatewayRequestID string
+	var gatewayTimestamp Timestamp
+	// 使用创建 Span 时的上下文回调，避免传入已被取消的超时上下文
+	defer func(ctx context.Context) {
+		metrics := RequestMetrics{
+			Operation: op,
+			Path:      path,
+			Duration:  time.Since(start),
+			Err:       err,
+		}
+		if resp != nil {
+			metrics.StatusCode = resp.StatusCode()
+		}
+		if s.latency != nil && resp != nil && resp.RawResponse != nil {
+			s.latency.record(path, resp.Time())
+		}
+		var sdkErr *SDKError
+		if errors.As(err, &sdkErr) {
+			metrics.Code = sdkErr.Code
+			if sdkErr.RequestID != "" {
+				gatewayRequestID = sdkErr.RequestID
+			}
+		}
+		observer.OnRequestEnd(ctx, metrics)
+
+		if options.responseMeta != nil && resp != nil && resp.RawResponse != nil {
+			*options.responseMeta = ResponseMeta{
+				RequestID:  gatewayRequestID,
+				StatusCode: resp.StatusCode(),
+				Timestamp:  gatewayTimestamp,
+				Header:     resp.Header(),
+			}
+		}
+
+		span.SetAttribute(SpanAttrOperation, op.String())
+		span.SetAttribute(SpanAttrStatusCode, metrics.StatusCode)
+		span.SetAttribute(SpanAttrCode, metrics.Code)
+		if gatewayRequestID != "" {
+			span.SetAttribute(SpanAttrRequestID, gatewayRequestID)
+		}
+		if traceID != "" {
+			span.SetAttribute(SpanAttrTraceID, traceID)
+		}
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}(ctx)
+
+	bizBodyBytes, err := json.Marshal(bizReq)
 	if err != nil {
-		return nil, &SDKError{
+		return annotate(&SDKError{
 			Code:       ErrInvalidResponse.Code,
 			Message:    fmt.Sprintf("failed to marshal request: %v", err),
 			StatusCode: 0,
-		}
+		})
+	}
+
+	timestamp := options.timestamp
+	if timestamp == 0 {
+		timestamp = s.config.now().UnixMilli()
 	}
 
 	haozReq := &HaozPayRequest{
-		MerchantNo: s.config.MerchantNo,
-		Timestamp:  currentTimestampMillis(),
+		MerchantNo: merchantNo,
+		Timestamp:  timestamp,
 		BizBody:    string(bizBodyBytes),
 	}
+	if s.config.NonceGenerator != nil {
+		haozReq.Nonce = s.config.NonceGenerator()
+	}
 
-	var result struct {
-		Response
-		Data *RefundResponse `json:"data"`
+	if options.dryRun || s.config.DryRun {
+		ctx = contextWithDryRun(ctx)
 	}
+	if options.formEncoding {
+		ctx = contextWithFormEncoding(ctx)
+	}
+	if options.bizBodySignOnly {
+		ctx = contextWithBizBodySignOnly(ctx)
+	}
+
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
+	// 流式解析时不让 resty 读取响应体，由 decodeResponseStream 边读取边解析
+	streamer, streaming := data.(responseStreamer)
 
-	_, err = s.client.R().
+	request := s.client.R().
 		SetContext(ctx).
-		SetBody(haozReq).
-		SetResult(&result).
-		Post("/pay-core/payment/refund")
+		SetHeaders(options.headers).
+		SetDoNotParseResponse(streaming)
+
+	// GET 接口没有请求体，签名参数作为查询参数发送，由 signatureMiddleware 按查询参数签名
+	method := op.method()
+	if method == http.MethodGet {
+		params, err := queryParamsFromRequest(haozReq)
+		if err != nil {
+			return annotate(&SDKError{
+				Code:       ErrInvalidRequest.Code,
+				Message:    fmt.Sprintf("failed to build query parameters: %v", err),
+				StatusCode: 0,
+			})
+		}
+		request.SetQueryParamsFromValues(params)
+	} else {
+		request.SetBody(haozReq)
+	}
+
+	resp, err = request.Execute(method, path)
 
 	if err != nil {
-		return nil, &SDKError{
+		// 试运行结果直接返回给调用方
+		var dryRun *DryRunError
+		if errors.As(err, &dryRun) {
+			return dryRun
+		}
+
+		// 响应体超过上限时 resty 停止读取，成功和错误响应都不会经过响应中间件
+		if errors.Is(err, resty.ErrResponseBodyTooLarge) {
+			return annotate(responseTooLargeError(resp.StatusCode(), s.config.MaxResponseBytes))
+		}
+
+		// 中间件返回的 SDKError 已包含具体错误信息，补充上下文后直接返回
+		var sdkErr *SDKError
+		if errors.As(err, &sdkErr) {
+			copied := *sdkErr
+			return annotate(&copied)
+		}
+		// 保留原始错误，调用方可通过 errors.Is 判断 ErrKeyParse、ErrSigning 等
+		return annotate(&SDKError{
 			Code:       ErrNetworkError.Code,
-			Message:    fmt.Sprintf("failed to create refund: %v", err),
+			Message:    fmt.Sprintf("request failed: %v", err),
 			StatusCode: 0,
-		}
+			cause:      err,
+		})
 	}
 
+	if streaming {
+		return s.decodeStreamResponse(resp, streamer, annotate, &gatewayRequestID, &gatewayTimestamp)
+	}
+
+	// 自行解析响应体而不是使用 SetResult，以便在解析失败时保留原始响应内容
+	body := resp.Body()
+	var result Response
+	if err := json.Unmarshal(body, &result); err != nil {
+		return annotate(&SDKError{
+			Code:       ErrInvalidResponse.Code,
+			Message:    fmt.Sprintf("failed to decode response: %v, body: %s", err, bodySnippet(body)),
+			StatusCode: resp.StatusCode(),
+		})
+	}
+	gatewayRequestID = result.RequestID
+	gatewayTimestamp = result.Timestamp
+
 	if result.Code != 0 {
-		return nil, NewSDKErrorWithRequestID(
+		sdkErr := NewSDKErrorWithRequestID(
 			result.Code,
 			result.Message,
 			0,
 			result.RequestID,
 		)
+		sdkErr.Details = result.Details
+		return annotate(sdkErr)
 	}
 
-	return result.Data, nil
-}
+	if data == nil {
+		return nil
+	}
 
-func (s *PaymentService) QueryRefund(ctx context.Context, req *QueryRefundRequest) (*QueryRefundResponse, error) {
-	bizBodyBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, &SDKError{
-			Code:       ErrInvalidResponse.Code,
-			Message:    fmt.Sprintf("failed to marshal request: %v", err),
-			StatusCode: 0,
+	if len(result.rawData) == 0 || string(result.rawData) == "null" {
+		// DoSignedRequest 调用的接口可能没有 data，由调用方自行判断
+		if raw, ok := data.(*json.RawMessage); ok {
+			*raw = nil
+			return nil
+		}
+		if options.allowEmptyData {
+			return nil
 		}
+		return annotate(NewSDKErrorWithRequestID(
+			ErrEmptyData.Code,
+			fmt.Sprintf("%s, body: %s", ErrEmptyData.Message, bodySnippet(body)),
+			resp.StatusCode(),
+			result.RequestID,
+		))
 	}
 
-	haozReq := &HaozPayRequest{
-		MerchantNo: s.config.MerchantNo,
-		Timestamp:  currentTimestampMillis(),
-		BizBody:    string(bizBodyBytes),
+	if err := json.Unmarshal(result.rawData, data); err != nil {
+		return annotate(NewSDKErrorWithRequestID(
+			ErrInvalidResponse.Code,
+			fmt.Sprintf("failed to decode response data: %v, body: %s", err, bodySnippet(body)),
+			resp.StatusCode(),
+			result.RequestID,
+		))
 	}
 
-	var result struct {
-		Response
-		Data *QueryRefundResponse `json:"data"`
+	if cacheKey != "" {
+		s.cache.Set(cacheKey, append([]byte(nil), result.rawData...), s.config.CacheTTL)
 	}
 
-	_, err = s.client.R().
-		SetContext(ctx).
-		SetBody(haozReq).
-		SetResult(&result).
-		Post("/pay-core/payment/refund/query")
+	return nil
+}
 
-	if err != nil {
-		return nil, &SDKError{
-			Code:       ErrNetworkError.Code,
-			Message:    fmt.Sprintf("failed to query refund: %v", err),
-			StatusCode: 0,
+// decodeStreamResponse 流式解析未被 resty 读取的响应体
+// 响应中间件不会处理此类响应，错误状态码在这里转换为 SDKError
+func (s *PaymentService) decodeStreamResponse(resp *resty.Response, streamer responseStreamer, annotate func(*SDKError) *SDKError, requestID *string, timestamp *Timestamp) error {
+	rawBody := resp.RawBody()
+	defer rawBody.Close()
+	body := limitResponseBody(rawBody, s.config.MaxResponseBytes)
+
+	if resp.StatusCode() >= 400 {
+		errBody, err := io.ReadAll(io.LimitReader(body, maxErrorBodySize))
+		if errors.Is(err, resty.ErrResponseBodyTooLarge) {
+			return annotate(responseTooLargeError(resp.StatusCode(), s.config.MaxResponseBytes))
 		}
+		sdkErr := errorFromResponse(resp, errBody)
+		*requestID = sdkErr.RequestID
+		return annotate(sdkErr)
 	}
 
+	result, err := decodeResponseStream(body, streamer)
+	*requestID = result.RequestID
+	*timestamp = result.Timestamp
+	if errors.Is(err, resty.ErrResponseBodyTooLarge) {
+		sdkErr := responseTooLargeError(resp.StatusCode(), s.config.MaxResponseBytes)
+		sdkErr.RequestID = result.RequestID
+		return annotate(sdkErr)
+	}
+	if err != nil {
+		return annotate(NewSDKErrorWithRequestID(
+			ErrInvalidResponse.Code,
+			fmt.Sprintf("failed to decode response: %v", err),
+			resp.StatusCode(),
+			result.RequestID,
+		))
+	}
 	if result.Code != 0 {
-		return nil, NewSDKErrorWithRequestID(
+		sdkErr := NewSDKErrorWithRequestID(
 			result.Code,
 			result.Message,
 			0,
 			result.RequestID,
 		)
+		sdkErr.Details = result.Details
+		return annotate(sdkErr)
 	}
+	return nil
+}
 
-	return result.Data, nil
+// queryParamsFromRequest 将请求转换为 GET 接口的查询参数
+// 查询参数与请求体签名时的参数相同(bizBody 展开后的字段以及 merchantNo、timestamp)，
+// 取值规则与 BuildSignString 相同，因此两种方式的签名字符串一致
+func queryParamsFromRequest(haozReq *HaozPayRequest) (url.Values, error) {
+	params, err := buildSignParams(haozReq)
+	if err != nil {
+		return nil, err
+	}
+	values := make(url.Values, len(params))
+	for key, value := range params {
+		if value == nil {
+			continue
+		}
+		valueStr := formatSignValue(value)
+		if strings.TrimSpace(valueStr) == "" {
+			continue
+		}
+		values.Set(key, valueStr)
+	}
+	return values, nil
+}
+
+// contextOrBackground 调用方传入 nil 上下文时返回 context.Background()
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
 }
 
-func currentTimestampMillis() int64 {
-	return time.Now().UnixMilli()
-}
\ No newline at end of file
+// maxBodySnippetLength 错误信息中保留的响应体最大长度
+const maxBodySnippetLength = 512
+
+// bodySnippet 截取响应体用于错误信息，超出长度的部分以省略号代替
+func bodySnippet(body []byte) string {
+	if len(body) <= maxBodySnippetLength {
+		return string(body)
+	}
+	return string(body[:maxBodySnippetLength]) + "..."
+}
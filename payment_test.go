@@ -0,0 +1,137 @@
+package haozpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestQueryOrderValidation nil 请求和 OrderNo 为空时返回 ErrInvalidRequest，不发送请求
+func TestQueryOrderValidation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+		okHandler(w, r)
+	}, func(cfg *Config) {
+		cfg.WithEndpointPath(OperationQueryOrder, "/pay-core/payment/order/query")
+	})
+
+	tests := []struct {
+		name string
+		req  *QueryOrderRequest
+	}{
+		{name: "nil request", req: nil},
+		{name: "empty OrderNo", req: &QueryOrderRequest{}},
+		{name: "whitespace OrderNo", req: &QueryOrderRequest{OrderNo: " "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Payment.QueryOrder(context.Background(), tt.req)
+			var sdkErr *SDKError
+			if !errors.As(err, &sdkErr) || sdkErr.Code != ErrInvalidRequest.Code {
+				t.Fatalf("QueryOrder(%+v) err = %v, want ErrInvalidRequest", tt.req, err)
+			}
+		})
+	}
+}
+
+// TestQueryOrderRequiresEndpointPath 未配置订单查询路径时返回 *ConfigError，配置后按该路径发送
+func TestQueryOrderRequiresEndpointPath(t *testing.T) {
+	var gotPath string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		okHandler(w, r)
+	}
+
+	client := newTestClient(t, handler)
+	_, err := client.Payment.QueryOrderByOrderNo(context.Background(), "ORDER001")
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("without endpoint path: err = %v, want *ConfigError", err)
+	}
+	if gotPath != "" {
+		t.Fatalf("request sent to %q without endpoint path", gotPath)
+	}
+
+	client = newTestClient(t, handler, func(cfg *Config) {
+		cfg.WithEndpointPath(OperationQueryOrder, "/custom/order/query")
+	})
+	if _, err := client.Payment.QueryOrderByOrderNo(context.Background(), "ORDER001"); err != nil {
+		t.Fatalf("with endpoint path: %v", err)
+	}
+	if gotPath != "/custom/order/query" {
+		t.Fatalf("path = %q, want /custom/order/query", gotPath)
+	}
+}
+
+// TestCreateValidation 下单和退款的参数校验失败时返回 ErrInvalidRequest，不发送请求
+func TestCreateValidation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+		okHandler(w, r)
+	})
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "nil order", call: func() error {
+			_, err := client.Payment.CreateOrder(context.Background(), nil)
+			return err
+		}},
+		{name: "order amount with three decimals", call: func() error {
+			_, err := client.Payment.CreateOrder(context.Background(), &CreatePaymentOrderRequest{
+				OrderTitle: "商品", OrderAmount: 0.015, NotifyUrl: "https://example.com/notify",
+			})
+			return err
+		}},
+		{name: "nil refund", call: func() error {
+			_, err := client.Payment.CreateRefund(context.Background(), nil)
+			return err
+		}},
+		{name: "refund without amount", call: func() error {
+			_, err := client.Payment.CreateRefund(context.Background(), &CreateRefundRequest{OrderNo: "ORDER001"})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sdkErr *SDKError
+			if err := tt.call(); !errors.As(err, &sdkErr) || sdkErr.Code != ErrInvalidRequest.Code {
+				t.Fatalf("err = %v, want ErrInvalidRequest", err)
+			}
+		})
+	}
+}
+
+// TestResponseMetaCacheHit 命中响应缓存时 ResponseMeta 只标记 Cached，不保留上一次请求的元信息
+func TestResponseMetaCacheHit(t *testing.T) {
+	queries := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		w.Header().Set("X-Request-Id", "GW1")
+		okHandler(w, r)
+	}, func(cfg *Config) {
+		cfg.WithResponseCache(time.Minute, NewMemoryResponseCache()).
+			WithEndpointPath(OperationQueryOrder, "/pay-core/payment/order/query")
+	})
+
+	var meta ResponseMeta
+	if _, err := client.Payment.QueryOrderByOrderNo(context.Background(), "ORDER001", WithResponseMeta(&meta)); err != nil {
+		t.Fatalf("first query: %v", err)
+	}
+	if meta.Cached || meta.StatusCode != http.StatusOK {
+		t.Fatalf("first query meta = %+v, want uncached 200", meta)
+	}
+
+	if _, err := client.Payment.QueryOrderByOrderNo(context.Background(), "ORDER001", WithResponseMeta(&meta)); err != nil {
+		t.Fatalf("cached query: %v", err)
+	}
+	if queries != 1 {
+		t.Fatalf("gateway queries = %d, want 1", queries)
+	}
+	if !meta.Cached || meta.StatusCode != 0 || meta.RequestID != "" || meta.Header != nil {
+		t.Fatalf("cached query meta = %+v, want only Cached set", meta)
+	}
+}
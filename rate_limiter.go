@@ -0,0 +1,98 @@
+package haozpay
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket 令牌桶限流器
+// 令牌以固定速率生成，桶容量决定允许的突发请求数
+type tokenBucket struct {
+	mu sync.Mutex
+	// rate 每秒生成的令牌数
+	rate float64
+	// burst 桶容量
+	burst float64
+	// tokens 当前令牌数，为负数时表示已被预留的令牌
+	tokens float64
+	// last 上次更新令牌数的时间
+	last time.Time
+}
+
+// newTokenBucket 创建令牌桶，初始时桶是满的
+//
+// 参数:
+//   - rate: 每秒生成的令牌数
+//   - burst: 桶容量，小于 1 时按 1 处理
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve 预留一个令牌，返回获得该令牌前需要等待的时间
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 按经过的时间补充令牌，不超过桶容量
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel 归还一个已预留但未使用的令牌
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// wait 阻塞直到获得令牌
+// 如果需要等待的时间超过上下文的截止时间，立即返回错误而不等待
+//
+// 返回:
+//   - error: 上下文被取消或等待时间超过截止时间时返回错误
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay := b.reserve(time.Now())
+	if delay == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		b.cancel()
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,72 @@
+package haozpay
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+)
+
+// checkRefundable 检查退款金额是否超过订单剩余可退金额
+// 查询订单金额和订单的已有退款，剩余可退金额 = 订单金额 - 成功、初始和处理中的退款金额
+//
+// 返回:
+//   - error: 超出时返回 *RefundExceedsError；查询失败时原样返回查询的错误(Operation 为 QueryOrder 或 QueryRefund)，此时不发送退款请求
+//
+// 注意:
+//   - 退款查询只能按订单号查询，退款请求只指定 ReqSeqId 时无法查询已有退款，返回 ErrInvalidRequest
+func (s *PaymentService) checkRefundable(ctx context.Context, req *CreateRefundRequest, options *requestOptions) error {
+	// 查询沿用本次退款的上下文和商户编号，不使用缓存，避免漏算刚完成的退款
+	queryOpts := []RequestOption{WithNoCache()}
+	if options.merchantNo != "" {
+		queryOpts = append(queryOpts, WithMerchantNo(options.merchantNo))
+	}
+
+	if req.OrderNo == "" {
+		// 订单查询响应中没有可用于退款查询的订单号，不能用 MerchantOrderNo 代替
+		return &SDKError{
+			Code:       ErrInvalidRequest.Code,
+			Message:    "refund precheck requires OrderNo: refunds cannot be queried by ReqSeqId",
+			StatusCode: 0,
+			Operation:  OperationCreateRefund,
+		}
+	}
+
+	// QueryOrder 要求 OrderNo 和 ReqSeqId 只能指定一个，优先按订单号查询
+	order, err := s.QueryOrder(ctx, &QueryOrderRequest{OrderNo: req.OrderNo}, queryOpts...)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return &SDKError{
+			Code:       ErrInvalidResponse.Code,
+			Message:    "refund precheck: order query returned no data",
+			StatusCode: 0,
+			Operation:  OperationQueryOrder,
+		}
+	}
+
+	orderNo := req.OrderNo
+	var refunded float64
+	refunds, err := s.QueryRefund(ctx, &QueryRefundRequest{OrderNo: orderNo}, queryOpts...)
+	var sdkErr *SDKError
+	switch {
+	case errors.As(err, &sdkErr) && sdkErr.StatusCode == http.StatusNotFound:
+		// 订单还没有退款
+	case err != nil:
+		return err
+	case refunds != nil:
+		refunded = refunds.refundedOrPending()
+	}
+
+	// 按分比较，避免浮点误差
+	refundable := math.Round((order.OrderAmount-refunded)*100) / 100
+	if math.Round(req.RefundAmount*100) > math.Round(refundable*100) {
+		return &RefundExceedsError{
+			OrderNo:      orderNo,
+			RefundAmount: req.RefundAmount,
+			Refundable:   refundable,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package haozpay
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// RefundStatus 退款状态
+// 网关以整数表示退款状态，兼容字符串编码(如 "2")
+type RefundStatus int
+
+const (
+	// RefundStatusInit 初始，退款申请已受理但尚未处理
+	RefundStatusInit RefundStatus = 0
+	// RefundStatusProcessing 处理中，等待渠道返回退款结果
+	RefundStatusProcessing RefundStatus = 1
+	// RefundStatusSuccess 退款成功
+	RefundStatusSuccess RefundStatus = 2
+	// RefundStatusFailed 退款失败
+	RefundStatusFailed RefundStatus = 3
+)
+
+// refundStatusNames 退款状态名称
+var refundStatusNames = map[RefundStatus]string{
+	RefundStatusInit:       "Init",
+	RefundStatusProcessing: "Processing",
+	RefundStatusSuccess:    "Success",
+	RefundStatusFailed:     "Failed",
+}
+
+// String 返回退款状态名称，未知状态返回 RefundStatus(n)
+func (s RefundStatus) String() string {
+	if name, ok := refundStatusNames[s]; ok {
+		return name
+	}
+	return "RefundStatus(" + strconv.Itoa(int(s)) + ")"
+}
+
+// IsSuccess 判断退款是否成功
+func (s RefundStatus) IsSuccess() bool {
+	return s == RefundStatusSuccess
+}
+
+// IsFailed 判断退款是否失败
+func (s RefundStatus) IsFailed() bool {
+	return s == RefundStatusFailed
+}
+
+// IsPending 判断退款是否尚未完成(初始或处理中)
+func (s RefundStatus) IsPending() bool {
+	return s == RefundStatusInit || s == RefundStatusProcessing
+}
+
+// IsTerminal 判断退款是否已到达终态(成功或失败)，到达终态后状态不再变化，轮询可以停止
+// 平台新增的未知状态不视为终态
+func (s RefundStatus) IsTerminal() bool {
+	return s == RefundStatusSuccess || s == RefundStatusFailed
+}
+
+// UnmarshalJSON 解析数字或字符串编码的退款状态，缺失或为 null 时为 RefundStatusInit
+func (s *RefundStatus) UnmarshalJSON(b []byte) error {
+	status, err := parseJSONInt(json.RawMessage(b), "refund status")
+	if err != nil {
+		return err
+	}
+	*s = RefundStatus(status)
+	return nil
+}
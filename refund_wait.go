@@ -0,0 +1,139 @@
+package haozpay
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RefundWaitOptions WaitForRefundDone 的轮询参数
+type RefundWaitOptions struct {
+	// Interval 首次轮询间隔，默认 2 秒
+	Interval time.Duration
+	// MaxInterval 最大轮询间隔，轮询间隔每次翻倍直到该值，默认 10 秒
+	MaxInterval time.Duration
+	// Timeout 轮询的总超时时间，默认 0 表示只受 ctx 控制
+	Timeout time.Duration
+}
+
+// WaitForRefundDone 轮询退款直到到达终态(退款成功或退款失败)
+// 退款由渠道异步处理，发起退款后可以使用该方法等待处理结果，
+// 轮询间隔从 Interval 开始逐次翻倍，不超过 MaxInterval
+//
+// 参数:
+//   - ctx: 上下文，取消或超时时停止轮询
+//   - req: 退款查询请求，指定 RefundSeqId 时等待该笔退款，否则等待订单的全部退款
+//   - opts: 轮询参数，可为 nil 使用默认值
+//
+// 返回:
+//   - *QueryRefundResponse: 最后一次查询到的退款记录，超时时可能为 nil
+//   - error: 超时时返回 ErrTimeout，查询返回非临时性错误时直接返回该错误
+//
+// 注意:
+//   - 每次查询都绕过查询缓存
+//   - 网络错误和网关 5xx 错误视为临时性错误，继续轮询
+//   - 终态由 RefundStatus.IsTerminal 判断，未知状态不视为终态
+//
+// 示例:
+//
+//	refund, err := client.Payment.WaitForRefundDone(ctx, &sdk.QueryRefundRequest{
+//	    OrderNo:     orderNo,
+//	    RefundSeqId: refundSeqId,
+//	}, &sdk.RefundWaitOptions{Timeout: 5 * time.Minute})
+//	if err == nil && refund.RefundStatus.IsFailed() {
+//	    log.Printf("退款失败: %s", refund.RefundStatusDesc)
+//	}
+func (s *PaymentService) WaitForRefundDone(ctx context.Context, req *QueryRefundRequest, opts *RefundWaitOptions, reqOpts ...RequestOption) (*QueryRefundResponse, error) {
+	ctx = contextOrBackground(ctx)
+	if req == nil {
+		return nil, &SDKError{
+			Code:       ErrInvalidRequest.Code,
+			Message:    "refund query request cannot be nil",
+			StatusCode: 0,
+			Operation:  OperationQueryRefund,
+		}
+	}
+	if opts == nil {
+		opts = &RefundWaitOptions{}
+	}
+
+	interval, maxInterval := waitIntervals(opts.Interval, opts.MaxInterval)
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	queryOpts := append([]RequestOption{WithNoCache()}, reqOpts...)
+
+	var last *QueryRefundResponse
+	for {
+		refund, err := s.QueryRefund(ctx, req, queryOpts...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, refundWaitTimeoutError(req, ctx.Err())
+			}
+			if !isTransientError(err) {
+				return last, err
+			}
+		} else {
+			last = refund
+			if refundDone(refund, req.RefundSeqId) {
+				return refund, nil
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return last, refundWaitTimeoutError(req, ctx.Err())
+		}
+
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// refundDone 判断退款是否已到达终态
+// 指定 refundSeqId 时只看该笔退款，否则要求订单的全部退款记录都已到达终态
+func refundDone(refund *QueryRefundResponse, refundSeqId string) bool {
+	if refund == nil {
+		return false
+	}
+	if len(refund.Records) == 0 {
+		return refund.RefundStatus.IsTerminal()
+	}
+	if refundSeqId != "" {
+		for _, record := range refund.Records {
+			if record != nil && record.RefundSeqId == refundSeqId {
+				return record.RefundStatus.IsTerminal()
+			}
+		}
+		return refund.RefundStatus.IsTerminal()
+	}
+	for _, record := range refund.Records {
+		if record != nil && !record.RefundStatus.IsTerminal() {
+			return false
+		}
+	}
+	return true
+}
+
+// refundWaitTimeoutError 退款轮询超时错误
+func refundWaitTimeoutError(req *QueryRefundRequest, cause error) *SDKError {
+	ref := req.OrderNo
+	if req.RefundSeqId != "" {
+		ref = req.OrderNo + "/" + req.RefundSeqId
+	}
+	return &SDKError{
+		Code:       ErrTimeout.Code,
+		Message:    fmt.Sprintf("refund %s did not reach a terminal state: %v", ref, cause),
+		StatusCode: 0,
+		Operation:  OperationQueryRefund,
+	}
+}
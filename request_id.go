@@ -0,0 +1,38 @@
+package haozpay
+
+import "context"
+
+// DefaultRequestIDHeader 默认透传请求ID使用的HTTP请求头
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// requestIDContextKey 请求ID在上下文中的键
+type requestIDContextKey struct{}
+
+// ContextWithRequestID 返回携带请求ID的上下文
+// 使用该上下文调用支付接口时，请求ID会通过 Config.RequestIDHeader 指定的请求头发送给网关，
+// 并记录在调试日志和返回的 SDKError.TraceID 中，便于与业务系统日志关联
+//
+// 参数:
+//   - ctx: 父上下文
+//   - requestID: 业务系统的请求ID或链路追踪ID
+//
+// 返回:
+//   - context.Context: 携带请求ID的上下文
+//
+// 示例:
+//
+//	ctx = sdk.ContextWithRequestID(ctx, traceID)
+//	order, err := client.Payment.CreateOrder(ctx, req)
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext 获取 ContextWithRequestID 设置的请求ID
+// 未设置时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
@@ -0,0 +1,230 @@
+package haozpay
+
+import (
+	"net/http"
+	"time"
+)
+
+// requestOptions 单次请求的可选参数
+type requestOptions struct {
+	// timestamp 请求时间戳(毫秒)，为 0 时使用配置的时钟
+	timestamp int64
+	// headers 附加的 HTTP 请求头
+	headers map[string]string
+	// timeout 本次请求的超时时间，为 0 时使用配置的超时时间
+	timeout time.Duration
+	// dryRun 是否只签名不发送
+	dryRun bool
+	// responseMeta 接收响应元信息，为 nil 时不记录
+	responseMeta *ResponseMeta
+	// noCache 是否跳过响应缓存
+	noCache bool
+	// formEncoding 是否以表单格式发送请求
+	formEncoding bool
+	// bizBodySignOnly 是否只对业务参数签名
+	bizBodySignOnly bool
+	// merchantNo 本次请求使用的商户编号，为空时使用上下文或配置中的商户编号
+	merchantNo string
+	// allowEmptyData 网关成功响应缺少 data 时是否不返回错误
+	allowEmptyData bool
+}
+
+// ResponseMeta 响应元信息
+// 通过 WithResponseMeta 获取，成功和失败的响应都会记录；
+// 查询命中响应缓存时没有发送请求，只有 Cached 为 true，其他字段均为零值
+type ResponseMeta struct {
+	// Cached 结果是否来自响应缓存
+	Cached bool
+	// RequestID 网关返回的请求ID
+	RequestID string
+	// StatusCode HTTP 状态码
+	StatusCode int
+	// Timestamp 网关返回的时间戳，网关未返回时为零值
+	Timestamp Timestamp
+	// Header 响应头
+	Header http.Header
+}
+
+// RequestOption 单次请求选项
+// 作为 PaymentService 各方法的可变参数传入，仅对本次调用生效
+type RequestOption func(*requestOptions)
+
+// newRequestOptions 应用请求选项
+func newRequestOptions(opts []RequestOption) *requestOptions {
+	o := &requestOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// WithTimestamp 指定本次请求的时间戳
+//
+// 参数:
+//   - timestamp: 毫秒时间戳，会参与签名
+//
+// 示例:
+//
+//	order, err := client.Payment.CreateOrder(ctx, req,
+//	    sdk.WithTimestamp(1735689600000))
+func WithTimestamp(timestamp int64) RequestOption {
+	return func(o *requestOptions) {
+		o.timestamp = timestamp
+	}
+}
+
+// WithHeader 为本次请求附加 HTTP 请求头
+// 可多次使用以设置多个请求头
+//
+// 参数:
+//   - key: 请求头名称
+//   - value: 请求头的值
+//
+// 示例:
+//
+//	order, err := client.Payment.CreateOrder(ctx, req,
+//	    sdk.WithHeader("X-Trace-Id", traceID))
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.headers == nil {
+			o.headers = make(map[string]string)
+		}
+		o.headers[key] = value
+	}
+}
+
+// WithTimeout 设置本次请求的超时时间
+// 与 ctx 自身的截止时间同时生效，以先到者为准
+//
+// 参数:
+//   - timeout: 超时时间，例如 5*time.Second
+//
+// 示例:
+//
+//	refund, err := client.Payment.QueryRefund(ctx, req,
+//	    sdk.WithTimeout(5*time.Second))
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithDryRun 开启本次请求的试运行模式
+// 请求完成签名后不会发送，接口返回 *DryRunError，其中包含签名后的完整请求内容
+//
+// 示例:
+//
+//	_, err := client.Payment.CreateOrder(ctx, req, sdk.WithDryRun())
+//	var dryRun *sdk.DryRunError
+//	if errors.As(err, &dryRun) {
+//	    fmt.Println(string(dryRun.Body))
+//	}
+func WithDryRun() RequestOption {
+	return func(o *requestOptions) {
+		o.dryRun = true
+	}
+}
+
+// WithResponseMeta 获取本次请求的响应元信息
+// 收到网关响应后将请求ID等信息写入 meta，便于在成功的交易中同样记录网关请求ID
+//
+// 参数:
+//   - meta: 接收响应元信息的指针，请求未收到响应时保持不变，命中响应缓存时 Cached 为 true
+//
+// 示例:
+//
+//	var meta sdk.ResponseMeta
+//	order, err := client.Payment.CreateOrder(ctx, req, sdk.WithResponseMeta(&meta))
+//	if err == nil {
+//	    log.Printf("下单成功，网关请求ID: %s", meta.RequestID)
+//	}
+func WithResponseMeta(meta *ResponseMeta) RequestOption {
+	return func(o *requestOptions) {
+		o.responseMeta = meta
+	}
+}
+
+// WithNoCache 本次查询跳过响应缓存
+// 直接向网关查询最新结果，查询成功后仍会更新缓存，仅在配置了 WithResponseCache 时有意义
+//
+// 示例:
+//
+//	order, err := client.Payment.QueryOrderByOrderNo(ctx, orderNo, sdk.WithNoCache())
+func WithNoCache() RequestOption {
+	return func(o *requestOptions) {
+		o.noCache = true
+	}
+}
+
+// WithAllowEmptyData 本次请求允许网关成功响应缺少 data
+// 默认情况下，下单、查询等接口在 code 为 0 但 data 缺失或为 null 时返回 ErrEmptyData，
+// 避免调用方拿到 nil 结果后空指针解引用；确认接口可能不返回数据时使用该选项
+//
+// 注意:
+//   - 使用该选项后，方法可能同时返回 nil 结果和 nil 错误，调用方必须先判断结果是否为 nil
+//
+// 示例:
+//
+//	refund, err := client.Payment.QueryRefund(ctx, req, sdk.WithAllowEmptyData())
+//	if err == nil && refund == nil {
+//	    log.Printf("暂无退款记录")
+//	}
+func WithAllowEmptyData() RequestOption {
+	return func(o *requestOptions) {
+		o.allowEmptyData = true
+	}
+}
+
+// WithFormEncoding 本次请求以 application/x-www-form-urlencoded 表单发送
+// 用于只接受表单请求的旧版接口，表单字段为参与签名的全部参数(bizBody 展开后的字段以及 merchantNo、timestamp)，
+// 按参数名升序排列，取值与签名字符串一致，sign 字段附加在最后
+//
+// 注意:
+//   - 响应仍按 JSON 解析
+//   - 表单请求不进行 gzip 压缩
+//
+// 示例:
+//
+//	order, err := client.Payment.QueryOrderByOrderNo(ctx, orderNo, sdk.WithFormEncoding())
+func WithFormEncoding() RequestOption {
+	return func(o *requestOptions) {
+		o.formEncoding = true
+	}
+}
+
+// WithBizBodySignOnly 本次请求只对业务参数签名
+// 默认签名参数包含 bizBody 展开后的字段以及 merchantNo、timestamp；
+// 部分旧版接口的签名字符串不包含 merchantNo 和 timestamp，调用这类接口时使用该选项，两者仍然随请求发送
+//
+// 注意:
+//   - SDK 内置的接口都按默认方式签名，不需要该选项，只在通过 DoSignedRequest 调用平台文档注明签名不含这两个字段的接口时使用
+//
+// 示例:
+//
+//	data, err := client.Payment.DoSignedRequest(ctx, "/pay-core/legacy/xxx", bizBody, sdk.WithBizBodySignOnly())
+func WithBizBodySignOnly() RequestOption {
+	return func(o *requestOptions) {
+		o.bizBodySignOnly = true
+	}
+}
+
+// WithMerchantNo 指定本次请求使用的商户编号
+// 覆盖 ContextWithMerchantNo 和 Config.MerchantNo，供服务商代子商户调用接口
+//
+// 参数:
+//   - merchantNo: 子商户编号，为空时不覆盖
+//
+// 注意:
+//   - 请求仍使用客户端配置的私钥或 Signer 签名，安全注意事项见 ContextWithMerchantNo
+//
+// 示例:
+//
+//	order, err := client.Payment.QueryOrderByOrderNo(ctx, orderNo,
+//	    sdk.WithMerchantNo(subMerchantNo))
+func WithMerchantNo(merchantNo string) RequestOption {
+	return func(o *requestOptions) {
+		o.merchantNo = merchantNo
+	}
+}
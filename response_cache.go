@@ -0,0 +1,91 @@
+package haozpay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ResponseCache 查询接口的响应缓存
+// 缓存内容为响应 data 字段的 JSON 原文，每次命中时重新解析，调用方之间不会共享返回的对象
+// 多实例部署时可使用共享存储(如 Redis)实现
+type ResponseCache interface {
+	// Get 返回未过期的缓存内容
+	Get(key string) ([]byte, bool)
+	// Set 写入缓存，ttl 后过期
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// cacheableOperations 可以缓存响应的只读操作
+// 下单、退款等写操作永远不会缓存；Ping 用于检查连通性，同样不缓存
+var cacheableOperations = map[Operation]bool{
+	OperationQueryOrder:      true,
+	OperationQueryRefund:     true,
+	OperationQueryRefundList: true,
+}
+
+// responseCacheKey 计算查询请求的缓存键
+// 请求签名包含时间戳，每次请求都不同，因此以操作、路径、商户编号和业务参数作为键
+func responseCacheKey(op Operation, path, merchantNo string, bizReq interface{}) (string, bool) {
+	bizBody, err := json.Marshal(bizReq)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(op.String() + "\n" + path + "\n" + merchantNo + "\n" + string(bizBody)))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// memoryResponseCache 进程内响应缓存
+type memoryResponseCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	// nextPrune 条目数达到该值时清理过期条目，清理后翻倍，使清理的开销均摊到每次写入
+	nextPrune int
+}
+
+// cacheEntry 缓存条目
+type cacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryResponseCache 创建进程内响应缓存
+// 过期条目在写入时批量清理，适用于单实例部署
+func NewMemoryResponseCache() ResponseCache {
+	return &memoryResponseCache{
+		entries:   make(map[string]cacheEntry),
+		nextPrune: 1024,
+	}
+}
+
+func (c *memoryResponseCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *memoryResponseCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.entries[key] = cacheEntry{value: value, expiresAt: now.Add(ttl)}
+	if len(c.entries) >= c.nextPrune {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.nextPrune = 2 * len(c.entries)
+		if c.nextPrune < 1024 {
+			c.nextPrune = 1024
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package haozpay
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// responseBodyLimiter 限制流式读取的响应体大小
+// 非流式响应由 resty 的 SetResponseBodyLimit 检查，流式响应不经过 resty 读取，由它在解析时检查，
+// 超出时返回与 resty 相同的 resty.ErrResponseBodyTooLarge
+type responseBodyLimiter struct {
+	r         io.Reader
+	remaining int
+}
+
+// limitResponseBody 按 limit 限制响应体的读取大小，limit 小于等于 0 时不限制
+func limitResponseBody(r io.Reader, limit int) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	return &responseBodyLimiter{r: r, remaining: limit}
+}
+
+func (l *responseBodyLimiter) Read(p []byte) (int, error) {
+	// 多读取一个字节，用于区分恰好达到上限和超出上限
+	if len(p) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= n
+	if l.remaining < 0 {
+		return n, resty.ErrResponseBodyTooLarge
+	}
+	return n, err
+}
+
+// responseTooLargeError 响应体超过 MaxResponseBytes 时返回的错误
+// 保留 resty.ErrResponseBodyTooLarge 作为原始错误
+func responseTooLargeError(statusCode, limit int) *SDKError {
+	return &SDKError{
+		Code:       ErrResponseTooLarge.Code,
+		Message:    fmt.Sprintf("response body exceeds MaxResponseBytes (%d bytes)", limit),
+		StatusCode: statusCode,
+		cause:      resty.ErrResponseBodyTooLarge,
+	}
+}
@@ -0,0 +1,159 @@
+package haozpay
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// errRetryAfterTooLong 网关要求的等待时间超过最大重试等待时间
+var errRetryAfterTooLong = errors.New("retry-after exceeds max retry wait time")
+
+// errRetryDeadline 等待后重试会超过上下文的截止时间
+var errRetryDeadline = errors.New("retry wait exceeds context deadline")
+
+// Backoff 带全抖动(full jitter)的指数退避策略
+// 第 n 次重试(从 0 开始)的等待时间在 [0, min(Max, Base*2^n)) 内均匀随机，
+// 避免网关故障时大量客户端同时重试
+//
+// 示例:
+//
+//	b := sdk.Backoff{Base: time.Second, Max: 5 * time.Second, Rand: func() float64 { return 0.5 }}
+//	b.Delay(2) // 2s
+type Backoff struct {
+	// Base 基础等待时间
+	Base time.Duration
+	// Max 最大等待时间，为 0 时不限制
+	Max time.Duration
+	// Rand 返回 [0, 1) 之间随机数的函数，默认使用 math/rand，可替换为固定值以得到确定的结果
+	Rand func() float64
+}
+
+// Delay 计算第 attempt 次重试前的等待时间
+//
+// 参数:
+//   - attempt: 重试序号，从 0 开始
+//
+// 返回:
+//   - time.Duration: 等待时间
+func (b Backoff) Delay(attempt int) time.Duration {
+	if b.Base <= 0 {
+		return 0
+	}
+	if attempt < 0 {
+		attempt = 0
+	}
+
+	ceiling := float64(b.Base) * math.Exp2(float64(attempt))
+	if b.Max > 0 && ceiling > float64(b.Max) {
+		ceiling = float64(b.Max)
+	}
+	if ceiling > math.MaxInt64 {
+		ceiling = math.MaxInt64
+	}
+
+	random := rand.Float64
+	if b.Rand != nil {
+		random = b.Rand
+	}
+	return time.Duration(random() * ceiling)
+}
+
+// retryCondition 重试条件
+//
+// 重试以下情况:
+//   - 网络错误(请求已发出但未收到响应)，与 resty 默认行为一致
+//   - HTTP 429 Too Many Requests
+//
+// 请求未发出(如签名失败)、响应体超过 MaxResponseBytes 或网关返回其他错误状态时不重试
+func retryCondition(resp *resty.Response, err error) bool {
+	if resp == nil || errors.Is(err, resty.ErrResponseBodyTooLarge) {
+		return false
+	}
+	if resp.RawResponse == nil {
+		return err != nil
+	}
+	return resp.StatusCode() == http.StatusTooManyRequests
+}
+
+// closeUnreadBody 重试前关闭上一次响应的响应体
+// 流式请求的响应体不会被 resty 读取和关闭，重试时需要释放连接；已读取的响应体重复关闭没有影响
+func closeUnreadBody(resp *resty.Response, _ error) {
+	if resp != nil && resp.RawResponse != nil {
+		resp.RawResponse.Body.Close()
+	}
+}
+
+// retryAfter 计算重试等待时间
+// 响应携带 Retry-After 时按网关要求等待，否则按 backoff 指数退避
+// 网关要求的等待时间超过 backoff.Max 时放弃重试，由调用方通过 SDKError.RetryAfter 自行决定退避
+// 等待后会超过请求上下文的截止时间时同样放弃重试，直接返回最近一次的错误
+//
+// 参数:
+//   - backoff: 退避策略
+//
+// 返回:
+//   - resty.RetryAfterFunc: resty 重试等待时间回调
+func retryAfter(backoff Backoff) resty.RetryAfterFunc {
+	return func(c *resty.Client, resp *resty.Response) (time.Duration, error) {
+		wait, ok := parseRetryAfter(resp.Header().Get("Retry-After"), time.Now())
+		if ok {
+			if backoff.Max > 0 && wait > backoff.Max {
+				return 0, errRetryAfterTooLong
+			}
+		} else {
+			// Attempt 为已完成的请求次数，首次重试对应序号 0
+			wait = backoff.Delay(resp.Request.Attempt - 1)
+		}
+
+		if deadline, ok := resp.Request.Context().Deadline(); ok && time.Until(deadline) < wait {
+			return 0, errRetryDeadline
+		}
+
+		// 返回 0 时 resty 会改用默认退避策略，使用最小的非零等待时间代替
+		if wait <= 0 {
+			wait = time.Nanosecond
+		}
+		return wait, nil
+	}
+}
+
+// parseRetryAfter 解析 Retry-After 响应头
+// 支持秒数(如 "120")和 HTTP 日期(如 "Wed, 21 Oct 2015 07:28:00 GMT")两种格式
+//
+// 参数:
+//   - value: Retry-After 响应头的值
+//   - now: 当前时间，用于计算 HTTP 日期格式的等待时间
+//
+// 返回:
+//   - time.Duration: 建议的等待时间，日期已过时为 0
+//   - bool: 响应头为空或格式无效时返回 false
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
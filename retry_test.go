@@ -0,0 +1,49 @@
+package haozpay
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestBackoffDelay 注入固定的随机数，逐次检查等待时间和 Max 上限
+func TestBackoffDelay(t *testing.T) {
+	fixed := func(v float64) func() float64 { return func() float64 { return v } }
+	almostOne := math.Nextafter(1, 0)
+
+	tests := []struct {
+		name    string
+		backoff Backoff
+		attempt int
+		want    time.Duration
+	}{
+		{name: "attempt 0 half", backoff: Backoff{Base: time.Second, Max: 5 * time.Second, Rand: fixed(0.5)}, attempt: 0, want: 500 * time.Millisecond},
+		{name: "attempt 1 half", backoff: Backoff{Base: time.Second, Max: 5 * time.Second, Rand: fixed(0.5)}, attempt: 1, want: time.Second},
+		{name: "attempt 2 half", backoff: Backoff{Base: time.Second, Max: 5 * time.Second, Rand: fixed(0.5)}, attempt: 2, want: 2 * time.Second},
+		{name: "attempt 3 capped", backoff: Backoff{Base: time.Second, Max: 5 * time.Second, Rand: fixed(0.5)}, attempt: 3, want: 2500 * time.Millisecond},
+		{name: "attempt 10 capped", backoff: Backoff{Base: time.Second, Max: 5 * time.Second, Rand: fixed(0.5)}, attempt: 10, want: 2500 * time.Millisecond},
+		{name: "zero jitter", backoff: Backoff{Base: time.Second, Max: 5 * time.Second, Rand: fixed(0)}, attempt: 4, want: 0},
+		{name: "max jitter stays below cap", backoff: Backoff{Base: time.Second, Max: 5 * time.Second, Rand: fixed(almostOne)}, attempt: 10, want: time.Duration(almostOne * float64(5*time.Second))},
+		{name: "no max", backoff: Backoff{Base: 100 * time.Millisecond, Rand: fixed(0.5)}, attempt: 5, want: 1600 * time.Millisecond},
+		{name: "negative attempt", backoff: Backoff{Base: time.Second, Max: 5 * time.Second, Rand: fixed(0.5)}, attempt: -3, want: 500 * time.Millisecond},
+		{name: "zero base", backoff: Backoff{Max: 5 * time.Second, Rand: fixed(0.5)}, attempt: 3, want: 0},
+		{name: "huge attempt without max", backoff: Backoff{Base: time.Second, Rand: fixed(0.5)}, attempt: 1 << 20, want: time.Duration(1 << 62)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.backoff.Delay(tt.attempt); got != tt.want {
+				t.Fatalf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBackoffDelayNeverExceedsMax 未注入随机数时，任意重试序号的等待时间都在 [0, Max) 内
+func TestBackoffDelayNeverExceedsMax(t *testing.T) {
+	b := Backoff{Base: 10 * time.Millisecond, Max: 80 * time.Millisecond}
+	for attempt := 0; attempt < 64; attempt++ {
+		if got := b.Delay(attempt); got < 0 || got >= b.Max {
+			t.Fatalf("Delay(%d) = %v, want in [0, %v)", attempt, got, b.Max)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package haozpay
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// reqSeqIdCounter 请求流水号序号，保证同一进程内同一秒生成的流水号单调递增
+var reqSeqIdCounter uint32
+
+// NewReqSeqId 生成请求流水号
+// 格式为 yyyyMMddHHmmss + 6位进程内序号 + 8位随机十六进制数，共 28 位，
+// 同一进程内按时间单调递增，多实例部署时由随机部分避免冲突
+//
+// 返回:
+//   - string: 请求流水号，例如 "20250101120000000001a3f09c2e"
+//
+// 示例:
+//
+//	reqSeqId := sdk.NewReqSeqId()
+func NewReqSeqId() string {
+	seq := atomic.AddUint32(&reqSeqIdCounter, 1) % 1000000
+
+	random := make([]byte, 4)
+	_, _ = rand.Read(random)
+
+	return fmt.Sprintf("%s%06d%s", time.Now().Format("20060102150405"), seq, hex.EncodeToString(random))
+}
+
+// NewNonce 生成请求随机数
+// 为 32 位随机十六进制数(16 字节)，可作为 Config.NonceGenerator 使用
+//
+// 返回:
+//   - string: 随机数，例如 "9f86d081884c7d659a2feaa0c55ad015"
+//
+// 示例:
+//
+//	config.WithNonceGenerator(sdk.NewNonce)
+func NewNonce() string {
+	random := make([]byte, 16)
+	_, _ = rand.Read(random)
+	return hex.EncodeToString(random)
+}
+
+// NewReqSeqId 使用配置的生成器生成请求流水号
+// 未配置 Config.ReqSeqIdGenerator 时使用 NewReqSeqId 生成
+//
+// 注意:
+//   - 退款请求中的 ReqSeqId 指原订单的请求流水号，用于定位订单，不能使用新生成的流水号
+func (s *PaymentService) NewReqSeqId() string {
+	if s.config.ReqSeqIdGenerator != nil {
+		return s.config.ReqSeqIdGenerator()
+	}
+	return NewReqSeqId()
+}
@@ -0,0 +1,29 @@
+package haozpay
+
+import "context"
+
+// bizBodySignOnlyContextKey 只对业务参数签名的标记在上下文中的键
+type bizBodySignOnlyContextKey struct{}
+
+// contextWithBizBodySignOnly 返回带有只对业务参数签名标记的上下文
+func contextWithBizBodySignOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bizBodySignOnlyContextKey{}, true)
+}
+
+// isBizBodySignOnly 判断上下文是否带有只对业务参数签名的标记
+func isBizBodySignOnly(ctx context.Context) bool {
+	only, _ := ctx.Value(bizBodySignOnlyContextKey{}).(bool)
+	return only
+}
+
+// scopeSignParams 按请求的签名约定调整参与签名的参数
+// 带有只对业务参数签名标记时去掉 merchantNo、timestamp 和 nonce，它们仍然随请求发送
+func scopeSignParams(ctx context.Context, params map[string]interface{}) map[string]interface{} {
+	if !isBizBodySignOnly(ctx) {
+		return params
+	}
+	delete(params, "merchantNo")
+	delete(params, "timestamp")
+	delete(params, "nonce")
+	return params
+}
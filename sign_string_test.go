@@ -0,0 +1,111 @@
+package haozpay
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestFormatSignValue 各类参数值在签名字符串中的文本
+// nil 值由 BuildSignString 跳过，不会交给 formatSignValue，见 TestBuildSignStringGolden
+func TestFormatSignValue(t *testing.T) {
+	type nested struct {
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{name: "string", value: "abc", want: "abc"},
+		{name: "bool true", value: true, want: "true"},
+		{name: "bool false", value: false, want: "false"},
+		{name: "int", value: 42, want: "42"},
+		{name: "negative int64", value: int64(-7), want: "-7"},
+		{name: "uint8", value: uint8(255), want: "255"},
+		{name: "float64", value: 1.5, want: "1.5"},
+		{name: "float64 integral", value: 1000000.0, want: "1000000"},
+		{name: "float64 small", value: 0.02, want: "0.02"},
+		{name: "float32", value: float32(0.1), want: "0.1"},
+		{name: "json.Number", value: json.Number("1.50"), want: "1.50"},
+		{name: "nested map", value: map[string]interface{}{"b": 1, "a": "x"}, want: `{"a":"x","b":1}`},
+		{name: "nested struct", value: nested{Name: "苹果<1>", Price: 1.5}, want: `{"name":"苹果<1>","price":1.5}`},
+		{name: "nil pointer", value: (*nested)(nil), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSignValue(tt.value); got != tt.want {
+				t.Fatalf("formatSignValue(%#v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBuildSignStringGolden 混合类型参数的签名字符串，nil、空字符串和 sign 不参与签名
+func TestBuildSignStringGolden(t *testing.T) {
+	params := map[string]interface{}{
+		"useHaozPayCashier": false,
+		"payType":           1,
+		"orderAmount":       99.9,
+		"orderTitle":        "测试订单",
+		"remark":            nil,
+		"redirectUrl":       "",
+		"sign":              "ignored",
+		"extra":             map[string]interface{}{"z": true, "a": 1.25},
+	}
+	want := `extra={"a":1.25,"z":true}&orderAmount=99.9&orderTitle=测试订单&payType=1&useHaozPayCashier=false`
+	if got := BuildSignString(params); got != want {
+		t.Fatalf("BuildSignString = %q\nwant %q", got, want)
+	}
+	if got, want := ComputeSignDigest(params), signDigest(want); got != want {
+		t.Fatalf("ComputeSignDigest = %s, want %s", got, want)
+	}
+}
+
+// TestGoodsDetailSignStringGolden 商品明细数组按紧凑 JSON 参与签名，
+// 结构体、map 和 bizBody 解析出的通用结构得到相同的签名字符串
+func TestGoodsDetailSignStringGolden(t *testing.T) {
+	type goods struct {
+		Quantity  int     `json:"quantity"`
+		GoodsName string  `json:"goodsName"`
+		Price     float64 `json:"price"`
+		GoodsId   string  `json:"goodsId,omitempty"`
+	}
+	detail := []goods{
+		{GoodsName: "苹果", Price: 1.5, Quantity: 2, GoodsId: "G1"},
+		{GoodsName: "A&B <礼盒>", Price: 100, Quantity: 1},
+	}
+	wantDetail := `[{"goodsId":"G1","goodsName":"苹果","price":1.5,"quantity":2},{"goodsName":"A&B <礼盒>","price":100,"quantity":1}]`
+
+	got, err := canonicalJSON(detail)
+	if err != nil {
+		t.Fatalf("canonicalJSON: %v", err)
+	}
+	if got != wantDetail {
+		t.Fatalf("canonicalJSON = %s\nwant %s", got, wantDetail)
+	}
+
+	wantSignString := `goodsDetail=` + wantDetail + `&merchantNo=M1&orderAmount=202.00&timestamp=1700000000000`
+
+	// 直接使用结构体构建签名参数
+	params := map[string]interface{}{
+		"goodsDetail": detail,
+		"merchantNo":  "M1",
+		"orderAmount": json.Number("202.00"),
+		"timestamp":   int64(1700000000000),
+	}
+	if got := BuildSignString(params); got != wantSignString {
+		t.Fatalf("BuildSignString(struct) = %s\nwant %s", got, wantSignString)
+	}
+
+	// 请求签名时从 bizBody 解析，键的顺序和数字文本与原始 JSON 无关
+	bizBody := `{"orderAmount":202.00,"goodsDetail":[{"quantity":2,"price":1.5,"goodsName":"苹果","goodsId":"G1"},{"price":100,"quantity":1,"goodsName":"A&B <礼盒>"}]}`
+	fromBody, err := buildSignParams(&HaozPayRequest{MerchantNo: "M1", Timestamp: 1700000000000, BizBody: bizBody})
+	if err != nil {
+		t.Fatalf("buildSignParams: %v", err)
+	}
+	if got := BuildSignString(fromBody); got != wantSignString {
+		t.Fatalf("BuildSignString(bizBody) = %s\nwant %s", got, wantSignString)
+	}
+}
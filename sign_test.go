@@ -0,0 +1,550 @@
+package haozpay
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testKey 测试共用的 2048 位密钥，生成一次后在各测试间复用
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+// testKeyPair 返回测试私钥及其 PKCS1 私钥和 PKIX 公钥的 PEM 文本
+func testKeyPair(t testing.TB) (*rsa.PrivateKey, string, string) {
+	t.Helper()
+	testKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		testKey = key
+	})
+	return testKey, privateKeyPEM(testKey), publicKeyPEM(t, &testKey.PublicKey)
+}
+
+// privateKeyPEM 将私钥编码为 PKCS1 PEM
+func privateKeyPEM(key *rsa.PrivateKey) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+// publicKeyPEM 将公钥编码为 PKIX PEM
+func publicKeyPEM(t testing.TB, key *rsa.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// newTestClient 创建连接到测试网关的客户端，私钥和平台公钥为同一密钥对，不重试
+func newTestClient(t testing.TB, handler http.HandlerFunc, configure ...func(*Config)) *Client {
+	t.Helper()
+	_, privPEM, pubPEM := testKeyPair(t)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := DefaultConfig().
+		WithBaseURL(server.URL).
+		WithMerchantNo("M1").
+		WithPrivateKey(privPEM).
+		WithPlatFormPublicKey(pubPEM).
+		WithRetry(0, 0, 0)
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// okHandler 返回业务成功响应的测试网关
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	_, _ = io.Copy(io.Discard, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"code":0,"message":"ok","data":{}}`))
+}
+
+// stringParams 将签名参数转换为回调参数的形式，供验签使用
+func stringParams(params map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(params))
+	for key, value := range params {
+		out[key] = formatSignValue(value)
+	}
+	return out
+}
+
+// TestConcurrentSigning 多个 goroutine 共用同一签名器时，首次解析私钥和签名都不应发生数据竞争
+// 需配合 go test -race 运行
+func TestConcurrentSigning(t *testing.T) {
+	key, privPEM, _ := testKeyPair(t)
+	signer := pemSigner{keys: newKeyCache(privPEM, "", DefaultMinKeyBits)}
+	verifier := NewPublicKeyVerifier(&key.PublicKey)
+
+	const workers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			params := map[string]interface{}{
+				"merchantNo": "M1",
+				"orderNo":    fmt.Sprintf("ORDER%03d", i),
+				"timestamp":  int64(1700000000000 + i),
+			}
+			sign, err := generateSignWithSigner(params, signer)
+			if err != nil {
+				errs <- fmt.Errorf("worker %d: sign: %w", i, err)
+				return
+			}
+			if err := verifyHaozPaySignature(verifier, stringParams(params), sign); err != nil {
+				errs <- fmt.Errorf("worker %d: verify: %w", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestConcurrentCreateOrderSharedRequest 50 个 goroutine 使用同一个下单请求并发调用 CreateOrder 时，
+// 每个请求都应带有可验证的签名，且共享的请求对象不被修改
+func TestConcurrentCreateOrderSharedRequest(t *testing.T) {
+	key, _, _ := testKeyPair(t)
+	verifier := NewPublicKeyVerifier(&key.PublicKey)
+	createPath := OperationCreateOrder.DefaultPath()
+
+	var mu sync.Mutex
+	var verifyErrs []error
+	received := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var sent HaozPayRequest
+		err := json.NewDecoder(r.Body).Decode(&sent)
+		if err == nil && r.URL.Path != createPath {
+			err = fmt.Errorf("request sent to %s, want %s", r.URL.Path, createPath)
+		}
+		if err == nil {
+			var params map[string]interface{}
+			if params, err = buildSignParams(&sent); err == nil {
+				err = verifyHaozPaySignature(verifier, stringParams(params), sent.Sign)
+			}
+		}
+		mu.Lock()
+		received++
+		if err != nil {
+			verifyErrs = append(verifyErrs, err)
+		}
+		mu.Unlock()
+		okHandler(w, r)
+	})
+
+	shared := &CreatePaymentOrderRequest{
+		OrderTitle:  "商品",
+		OrderAmount: 0.01,
+		NotifyUrl:   "https://example.com/notify",
+	}
+	want := *shared
+
+	const workers = 50
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Payment.CreateOrder(context.Background(), shared); err != nil {
+				t.Errorf("CreateOrder: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if *shared != want {
+		t.Errorf("shared request was mutated: got %+v, want %+v", *shared, want)
+	}
+	if received != workers {
+		t.Errorf("gateway received %d requests, want %d", received, workers)
+	}
+	for _, err := range verifyErrs {
+		t.Errorf("gateway rejected signature: %v", err)
+	}
+}
+
+// findLeadingZeroSignature 查找签名首字节为 0 的摘要
+// 签名是小于模数的大整数，约每 256 个摘要中有一个签名以 0x00 开头
+func findLeadingZeroSignature(t *testing.T, key *rsa.PrivateKey) ([]byte, []byte) {
+	t.Helper()
+	for i := 0; i < 1<<14; i++ {
+		digest := []byte(signDigest(fmt.Sprintf("orderNo=ORDER%d", i)))
+		sig, err := privateKeyEncryptRaw(key, digest)
+		if err != nil {
+			t.Fatalf("privateKeyEncryptRaw: %v", err)
+		}
+		if sig[0] == 0x00 {
+			return digest, sig
+		}
+	}
+	t.Fatal("no signature with a leading zero byte found")
+	return nil, nil
+}
+
+// TestRawSignLeadingZeros 签名或摘要以 0x00 开头时，签名和验签应能完整往返
+func TestRawSignLeadingZeros(t *testing.T) {
+	key, _, _ := testKeyPair(t)
+	zeroSigDigest, zeroSig := findLeadingZeroSignature(t, key)
+
+	tests := []struct {
+		name string
+		data []byte
+		// sig 为 nil 时使用 privateKeyEncryptRaw 的结果
+		sig []byte
+	}{
+		{name: "hex digest", data: []byte(signDigest("orderNo=ORDER001"))},
+		{name: "one leading zero byte", data: append([]byte{0x00}, []byte("digest")...)},
+		{name: "several leading zero bytes", data: []byte{0x00, 0x00, 0x00, 0x01, 0x02}},
+		{name: "all zero bytes", data: make([]byte, 32)},
+		{name: "signature with leading zero", data: zeroSigDigest, sig: zeroSig},
+		{name: "signature with leading zero stripped", data: zeroSigDigest, sig: zeroSig[1:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := tt.sig
+			if sig == nil {
+				var err error
+				sig, err = privateKeyEncryptRaw(key, tt.data)
+				if err != nil {
+					t.Fatalf("privateKeyEncryptRaw: %v", err)
+				}
+				if len(sig) != key.Size() {
+					t.Fatalf("signature length = %d, want %d", len(sig), key.Size())
+				}
+			}
+
+			got, err := decryptWithPublicKey(&key.PublicKey, sig)
+			if err != nil {
+				t.Fatalf("decryptWithPublicKey: %v", err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Fatalf("round trip = %x, want %x", got, tt.data)
+			}
+			if err := verifyDigest(&key.PublicKey, tt.data, sig); err != nil {
+				t.Fatalf("verifyDigest: %v", err)
+			}
+		})
+	}
+}
+
+// TestChunkedEncryptRoundTrip 分段加密的结果按 k 字节分块，分段解密后与原文一致
+func TestChunkedEncryptRoundTrip(t *testing.T) {
+	key, _, _ := testKeyPair(t)
+	k := key.Size()
+	blockSize := k - 11
+
+	tests := []struct {
+		name   string
+		data   []byte
+		blocks int
+	}{
+		{name: "empty", data: []byte{}, blocks: 0},
+		{name: "short", data: []byte("orderNo=ORDER001"), blocks: 1},
+		{name: "exactly one block", data: bytes.Repeat([]byte{0x5A}, blockSize), blocks: 1},
+		{name: "one byte over a block", data: bytes.Repeat([]byte{0x5A}, blockSize+1), blocks: 2},
+		{name: "multiple blocks", data: bytes.Repeat([]byte("0123456789"), 3*blockSize/10+7), blocks: 4},
+		{name: "leading zero bytes in later block", data: append(bytes.Repeat([]byte{0x01}, blockSize), 0x00, 0x00, 0x02), blocks: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := privateKeyEncryptChunked(key, tt.data)
+			if err != nil {
+				t.Fatalf("privateKeyEncryptChunked: %v", err)
+			}
+			if len(encrypted) != tt.blocks*k {
+				t.Fatalf("ciphertext length = %d, want %d blocks of %d bytes", len(encrypted), tt.blocks, k)
+			}
+			decrypted, err := decryptChunkedWithPublicKey(&key.PublicKey, encrypted)
+			if err != nil {
+				t.Fatalf("decryptChunkedWithPublicKey: %v", err)
+			}
+			if !bytes.Equal(decrypted, tt.data) {
+				t.Fatalf("round trip = %x, want %x", decrypted, tt.data)
+			}
+		})
+	}
+}
+
+// TestDecryptChunkedInvalid 密文长度不是 k 的整数倍或某一块损坏时返回错误
+func TestDecryptChunkedInvalid(t *testing.T) {
+	key, _, _ := testKeyPair(t)
+	k := key.Size()
+	encrypted, err := privateKeyEncryptChunked(key, bytes.Repeat([]byte{0x5A}, 2*(k-11)))
+	if err != nil {
+		t.Fatalf("privateKeyEncryptChunked: %v", err)
+	}
+
+	corrupted := append([]byte(nil), encrypted...)
+	corrupted[k+k/2] ^= 0xFF
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "one byte short", data: encrypted[:len(encrypted)-1], want: "invalid ciphertext length"},
+		{name: "one byte over", data: append(append([]byte(nil), encrypted...), 0x00), want: "invalid ciphertext length"},
+		{name: "partial block", data: encrypted[:k/2], want: "invalid ciphertext length"},
+		{name: "corrupted second block", data: corrupted, want: "block 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decryptChunkedWithPublicKey(&key.PublicKey, tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestVerifyMalformedSignature 损坏、截断、超长和非 Base64 的签名都应返回错误而不是 panic
+func TestVerifyMalformedSignature(t *testing.T) {
+	key, _, _ := testKeyPair(t)
+	verifier := NewPublicKeyVerifier(&key.PublicKey)
+	params := map[string]string{"merchantNo": "M1", "orderNo": "ORDER001", "timestamp": "1700000000000"}
+
+	fields := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		fields[k] = v
+	}
+	valid, err := generateSignWithKey(fields, key)
+	if err != nil {
+		t.Fatalf("generateSignWithKey: %v", err)
+	}
+	if err := verifyHaozPaySignature(verifier, params, valid); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(valid)
+
+	corrupted := append([]byte(nil), raw...)
+	corrupted[len(corrupted)/2] ^= 0xFF
+	overModulus := bytes.Repeat([]byte{0xFF}, key.Size())
+
+	tests := []struct {
+		name      string
+		signature string
+	}{
+		{name: "empty", signature: ""},
+		{name: "corrupted byte", signature: base64.StdEncoding.EncodeToString(corrupted)},
+		{name: "truncated", signature: base64.StdEncoding.EncodeToString(raw[:len(raw)/2])},
+		{name: "single byte", signature: base64.StdEncoding.EncodeToString(raw[:1])},
+		{name: "overlong", signature: base64.StdEncoding.EncodeToString(append(append([]byte(nil), raw...), 0x00, 0x01))},
+		{name: "not less than modulus", signature: base64.StdEncoding.EncodeToString(overModulus)},
+		{name: "truncated base64 text", signature: valid[:len(valid)-3]},
+		{name: "non-base64 characters", signature: "!!not*base64!!"},
+		{name: "whitespace", signature: "   "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifyHaozPaySignature(verifier, params, tt.signature); err == nil {
+				t.Fatalf("verifyHaozPaySignature(%q) = nil, want error", tt.signature)
+			}
+		})
+	}
+}
+
+// TestDecodeSignatureInvalid 非 Base64 文本应返回解码错误
+func TestDecodeSignatureInvalid(t *testing.T) {
+	for _, signature := range []string{"!!!!", "a", "ab=c", "a\x00bc"} {
+		if got, err := decodeSignature(signature); err == nil {
+			t.Errorf("decodeSignature(%q) = %x, want error", signature, got)
+		}
+	}
+}
+
+// TestDecodeSignatureEncodings 标准和 URL 安全的 Base64 签名，带或不带填充都能解码并验签
+func TestDecodeSignatureEncodings(t *testing.T) {
+	key, _, _ := testKeyPair(t)
+	verifier := NewPublicKeyVerifier(&key.PublicKey)
+
+	// 查找签名中同时包含 + 和 / 且需要填充的参数，保证四种编码得到不同的文本
+	var params map[string]string
+	var raw []byte
+	for i := 0; raw == nil; i++ {
+		p := map[string]interface{}{"orderNo": fmt.Sprintf("ORDER%d", i)}
+		sign, err := generateSignWithKey(p, key)
+		if err != nil {
+			t.Fatalf("generateSignWithKey: %v", err)
+		}
+		if strings.ContainsAny(sign, "+") && strings.ContainsAny(sign, "/") && strings.HasSuffix(sign, "=") {
+			params = stringParams(p)
+			raw, _ = base64.StdEncoding.DecodeString(sign)
+		}
+	}
+
+	for _, enc := range []struct {
+		name     string
+		encoding *base64.Encoding
+	}{
+		{name: "standard padded", encoding: base64.StdEncoding},
+		{name: "standard unpadded", encoding: base64.RawStdEncoding},
+		{name: "url-safe padded", encoding: base64.URLEncoding},
+		{name: "url-safe unpadded", encoding: base64.RawURLEncoding},
+	} {
+		t.Run(enc.name, func(t *testing.T) {
+			signature := enc.encoding.EncodeToString(raw)
+			got, err := decodeSignature(signature)
+			if err != nil {
+				t.Fatalf("decodeSignature(%q): %v", signature, err)
+			}
+			if !bytes.Equal(got, raw) {
+				t.Fatal("decoded signature does not match")
+			}
+			if err := verifyHaozPaySignature(verifier, params, signature); err != nil {
+				t.Fatalf("verifyHaozPaySignature: %v", err)
+			}
+		})
+	}
+}
+
+// TestFixedClockDeterministicRequest 固定时钟且不生成随机数时，同一业务请求签名后的报文完全相同
+func TestFixedClockDeterministicRequest(t *testing.T) {
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	client := newTestClient(t, okHandler, func(cfg *Config) {
+		cfg.WithClock(func() time.Time { return fixed })
+	})
+	req := &CreatePaymentOrderRequest{OrderTitle: "测试订单", OrderAmount: 1.5, PayType: 1, NotifyUrl: "https://example.com/notify"}
+
+	dryRun := func() *DryRunError {
+		t.Helper()
+		_, err := client.Payment.CreateOrder(context.Background(), req, WithDryRun())
+		var dr *DryRunError
+		if !errors.As(err, &dr) {
+			t.Fatalf("CreateOrder err = %v, want *DryRunError", err)
+		}
+		return dr
+	}
+	first, second := dryRun(), dryRun()
+
+	if first.Request.Timestamp != fixed.UnixMilli() {
+		t.Fatalf("timestamp = %d, want %d", first.Request.Timestamp, fixed.UnixMilli())
+	}
+	if !bytes.Equal(first.Body, second.Body) {
+		t.Fatalf("signed bodies differ:\n%s\n%s", first.Body, second.Body)
+	}
+	wantSignString := "merchantNo=M1&notifyUrl=https://example.com/notify&orderAmount=1.5&orderTitle=测试订单&payType=1&timestamp=1704164645000&useHaozPayCashier=false"
+	if first.SignString != wantSignString {
+		t.Fatalf("sign string = %q\nwant %q", first.SignString, wantSignString)
+	}
+}
+
+// textbookPrivateKeyEncrypt 改用 rsa.SignPKCS1v15 之前的签名实现，仅供对照测试和基准测试使用
+// 手工填充 block type 1 后直接计算 m^d mod n，不使用 CRT 预计算值
+func textbookPrivateKeyEncrypt(privateKey *rsa.PrivateKey, data []byte) []byte {
+	k := privateKey.Size()
+	em := make([]byte, k)
+	em[1] = 0x01
+	for i := 2; i < k-len(data)-1; i++ {
+		em[i] = 0xFF
+	}
+	copy(em[k-len(data):], data)
+
+	m := new(big.Int).SetBytes(em)
+	c := new(big.Int).Exp(m, privateKey.D, privateKey.N)
+	return c.FillBytes(make([]byte, k))
+}
+
+// TestPrivateKeyEncryptRawMatchesTextbook CRT 签名的结果应与直接计算 m^d mod n 逐字节一致
+func TestPrivateKeyEncryptRawMatchesTextbook(t *testing.T) {
+	key, _, _ := testKeyPair(t)
+	zeroSigDigest, _ := findLeadingZeroSignature(t, key)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "hex digest", data: []byte(signDigest("orderNo=ORDER001"))},
+		{name: "empty", data: []byte{}},
+		{name: "single byte", data: []byte{0x01}},
+		{name: "leading zero bytes", data: []byte{0x00, 0x00, 0x01}},
+		{name: "max length", data: bytes.Repeat([]byte{0xAB}, key.Size()-11)},
+		{name: "signature with leading zero", data: zeroSigDigest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := privateKeyEncryptRaw(key, tt.data)
+			if err != nil {
+				t.Fatalf("privateKeyEncryptRaw: %v", err)
+			}
+			if want := textbookPrivateKeyEncrypt(key, tt.data); !bytes.Equal(got, want) {
+				t.Fatalf("privateKeyEncryptRaw = %x\nwant textbook %x", got, want)
+			}
+		})
+	}
+}
+
+// BenchmarkPrivateKeyEncryptRaw 使用 CRT 预计算值的当前签名实现
+func BenchmarkPrivateKeyEncryptRaw(b *testing.B) {
+	key, _, _ := testKeyPair(b)
+	digest := []byte(signDigest("orderNo=ORDER001"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := privateKeyEncryptRaw(key, digest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPrivateKeyEncryptTextbook 直接计算 m^d mod n 的原签名实现，与 BenchmarkPrivateKeyEncryptRaw 对比
+func BenchmarkPrivateKeyEncryptTextbook(b *testing.B) {
+	key, _, _ := testKeyPair(b)
+	digest := []byte(signDigest("orderNo=ORDER001"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		textbookPrivateKeyEncrypt(key, digest)
+	}
+}
+
+// BenchmarkGenerateSign 包级 GenerateSign，每次调用都解析 PEM 私钥
+func BenchmarkGenerateSign(b *testing.B) {
+	_, privPEM, _ := testKeyPair(b)
+	params := map[string]interface{}{"merchantNo": "M1", "orderNo": "ORDER001", "orderAmount": 1.5, "timestamp": int64(1700000000000)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := GenerateSign(params, privPEM); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGenerateSignWithKey 使用已解析的私钥签名，即客户端请求签名的开销
+func BenchmarkGenerateSignWithKey(b *testing.B) {
+	key, _, _ := testKeyPair(b)
+	params := map[string]interface{}{"merchantNo": "M1", "orderNo": "ORDER001", "orderAmount": 1.5, "timestamp": int64(1700000000000)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := generateSignWithKey(params, key); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
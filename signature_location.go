@@ -0,0 +1,38 @@
+package haozpay
+
+// SignatureLocation 请求签名的传递位置
+// 签名算法和签名内容相同，区别只在于签名放在请求体的 sign 字段还是 HTTP 请求头中
+type SignatureLocation int
+
+const (
+	// SignatureInBody 签名写入请求体的 sign 字段，默认方式
+	SignatureInBody SignatureLocation = iota
+	// SignatureInHeader 签名写入 Config.SignatureHeader 指定的请求头，商户编号和时间戳同时写入
+	// HeaderMerchantNo 和 HeaderTimestamp，请求体不包含 sign 字段；开启 Config.SignHeaders 后这些请求头也参与签名
+	SignatureInHeader
+)
+
+const (
+	// DefaultSignatureHeader 签名写入请求头时默认使用的请求头
+	DefaultSignatureHeader = "X-Haoz-Sign"
+	// HeaderMerchantNo 签名写入请求头时携带商户编号的请求头
+	HeaderMerchantNo = "X-Haoz-Merchant-No"
+	// HeaderTimestamp 签名写入请求头时携带请求时间戳(毫秒)的请求头
+	HeaderTimestamp = "X-Haoz-Timestamp"
+	// HeaderNonce 签名写入请求头时携带请求随机数的请求头，配置了 Config.NonceGenerator 时发送
+	HeaderNonce = "X-Haoz-Nonce"
+)
+
+// signatureLocationNames 签名位置名称
+var signatureLocationNames = map[SignatureLocation]string{
+	SignatureInBody:   "Body",
+	SignatureInHeader: "Header",
+}
+
+// String 返回签名位置名称
+func (l SignatureLocation) String() string {
+	if name, ok := signatureLocationNames[l]; ok {
+		return name
+	}
+	return "Unknown"
+}
@@ -0,0 +1,64 @@
+package haozpay
+
+import (
+	"net/http"
+	"strings"
+)
+
+// signedHeaders 开启 Config.SignHeaders 后参与签名的请求头，即签名写入请求头时 SDK 发送的全部元信息请求头
+// 请求头以小写名称作为参数名加入签名参数，例如 x-haoz-timestamp=1700000000000，
+// 与其他参数一起按参数名升序排列；未设置或值为空的请求头不参与签名
+var signedHeaders = []string{HeaderMerchantNo, HeaderTimestamp, HeaderNonce}
+
+// signedHeaderParams 收集规范请求头集合中已设置的请求头
+//
+// 参数:
+//   - header: 请求、响应或回调的 HTTP 头
+//
+// 返回:
+//   - map[string]string: 以小写请求头名为键的参数，未设置的请求头不包含在内
+func signedHeaderParams(header http.Header) map[string]string {
+	params := make(map[string]string, len(signedHeaders))
+	for _, name := range signedHeaders {
+		if value := header.Get(name); value != "" {
+			params[strings.ToLower(name)] = value
+		}
+	}
+	return params
+}
+
+// addSignedHeaders 将规范请求头加入签名参数
+func addSignedHeaders(params map[string]interface{}, header http.Header) {
+	for key, value := range signedHeaderParams(header) {
+		params[key] = value
+	}
+}
+
+// VerifyCallbackWithHeaders 验证回调签名，开启 Config.SignHeaders 时同时校验规范请求头
+// 平台以请求头方式签名的回调中，X-Haoz-Merchant-No、X-Haoz-Timestamp、X-Haoz-Nonce 请求头
+// 与回调参数一起参与签名，验签时按与请求签名相同的规则还原
+//
+// 参数:
+//   - params: 回调参数（不包含 sign）
+//   - header: 回调请求的 HTTP 头
+//   - signature: 回调签名
+//
+// 返回:
+//   - error: 与 VerifyCallback 相同
+//
+// 注意:
+//   - 未开启 Config.SignHeaders 时与 VerifyCallback 相同，请求头被忽略
+//   - ParseNotification 和 NotificationHandler 使用该方法验签
+func (c *Client) VerifyCallbackWithHeaders(params map[string]string, header http.Header, signature string) error {
+	if !c.config.SignHeaders {
+		return c.VerifyCallback(params, signature)
+	}
+	merged := make(map[string]string, len(params)+len(signedHeaders))
+	for key, value := range params {
+		merged[key] = value
+	}
+	for key, value := range signedHeaderParams(header) {
+		merged[key] = value
+	}
+	return c.VerifyCallback(merged, signature)
+}
@@ -0,0 +1,49 @@
+package haozpay
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// DoSignedRequest 调用 SDK 尚未封装的接口
+// 与其他接口相同，bizBody 序列化后封装为 HaozPayRequest 并签名，POST 到 path，
+// 检查业务状态码后返回响应 data 字段的原文，由调用方按接口文档解析
+//
+// 参数:
+//   - ctx: 上下文
+//   - path: 接口路径，必须以 / 开头，例如 /pay-core/payment/xxx
+//   - bizBody: 业务请求参数，序列化后作为 bizBody
+//   - opts: 单次请求选项
+//
+// 返回:
+//   - json.RawMessage: 响应 data 字段的 JSON 原文，接口没有返回 data 时为 nil
+//   - error: 请求失败或业务状态码不为 0 时返回错误，错误中的 Operation 为 OperationDoSignedRequest
+//
+// 注意:
+//   - 仅用于平台新增接口、SDK 尚未提供对应方法的过渡期，SDK 提供方法后应改用类型化的方法
+//   - 不会缓存响应，也不会进行参数校验
+//
+// 示例:
+//
+//	data, err := client.Payment.DoSignedRequest(ctx, "/pay-core/payment/close", map[string]interface{}{
+//	    "orderNo": "ORDER123456",
+//	})
+//	var result CloseResult
+//	err = json.Unmarshal(data, &result)
+func (s *PaymentService) DoSignedRequest(ctx context.Context, path string, bizBody interface{}, opts ...RequestOption) (json.RawMessage, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, &SDKError{
+			Code:       ErrInvalidRequest.Code,
+			Message:    "path must start with /",
+			StatusCode: 0,
+			Operation:  OperationDoSignedRequest,
+		}
+	}
+
+	var data json.RawMessage
+	if err := s.doRequest(ctx, OperationDoSignedRequest, path, bizBody, &data, opts...); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
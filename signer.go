@@ -0,0 +1,174 @@
+package haozpay
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+)
+
+// Signer 请求签名器
+// 默认使用配置的 PEM 私钥在进程内签名；私钥保存在 HSM 或 KMS 中、不能导出时，
+// 可通过 Config.Signer 将 RSA 私钥运算委托给外部实现
+type Signer interface {
+	// Sign 对摘要签名
+	// digest 为签名字符串 SHA256 摘要的小写十六进制文本(64 字节)，
+	// 实现需对其做 PKCS#1 v1.5 block type 1 填充后进行 RSA 私钥运算，不添加 DigestInfo，
+	// 返回与密钥模长等长的签名，即 rsa.SignPKCS1v15(nil, key, 0, digest) 的结果，
+	// 对应 PKCS#11 的 CKM_RSA_PKCS 机制
+	Sign(digest []byte) ([]byte, error)
+}
+
+// pemSigner 使用 PEM 私钥在进程内签名的默认实现，私钥在首次签名时解析并缓存
+type pemSigner struct {
+	keys *keyCache
+}
+
+func (s pemSigner) Sign(digest []byte) ([]byte, error) {
+	privateKey, err := s.keys.getPrivateKey()
+	if err != nil {
+		return nil, newLocalizedError(ErrKeyParse, msgParsePrivateKey, err)
+	}
+	return privateKeyEncryptRaw(privateKey, digest)
+}
+
+// NewPEMSigner 创建使用 PEM 私钥签名的签名器
+// 与未配置 Config.Signer 时的默认行为一致
+//
+// 参数:
+//   - privateKeyPEM: 商户私钥（支持纯私钥字符串或完整PEM格式），在首次签名时解析
+//   - opts: 密钥选项，私钥默认至少为 DefaultMinKeyBits 位，可通过 WithMinKeyBits 放宽
+//
+// 返回:
+//   - Signer: 签名器
+func NewPEMSigner(privateKeyPEM string, opts ...KeyOption) Signer {
+	return pemSigner{keys: newKeyCache(privateKeyPEM, "", minKeyBitsOf(opts))}
+}
+
+// cryptoSigner 将标准库 crypto.Signer 适配为 Signer
+type cryptoSigner struct {
+	signer crypto.Signer
+}
+
+func (s cryptoSigner) Sign(digest []byte) ([]byte, error) {
+	// 哈希算法为 0 时 RSA 实现直接对 digest 做 PKCS#1 v1.5 签名，不添加 DigestInfo
+	return s.signer.Sign(rand.Reader, digest, crypto.Hash(0))
+}
+
+// NewCryptoSigner 将 crypto.Signer 适配为 Signer
+// 适用于以 crypto.Signer 形式提供 RSA 私钥的 HSM(PKCS#11)和云 KMS 客户端库
+//
+// 参数:
+//   - signer: RSA 私钥签名器，需支持以 crypto.Hash(0) 对任意数据做 PKCS#1 v1.5 签名
+//
+// 返回:
+//   - Signer: 签名器
+//
+// 示例:
+//
+//	config.WithSigner(sdk.NewCryptoSigner(hsmKey))
+func NewCryptoSigner(signer crypto.Signer) Signer {
+	return cryptoSigner{signer: signer}
+}
+
+// rsaKeySigner 使用已解析的私钥签名
+type rsaKeySigner struct {
+	privateKey *rsa.PrivateKey
+}
+
+func (s rsaKeySigner) Sign(digest []byte) ([]byte, error) {
+	return privateKeyEncryptRaw(s.privateKey, digest)
+}
+
+// Verifier 签名验证器
+// 默认使用配置的平台 PEM 公钥验签；公钥由 KMS 托管或需要同时接受多把公钥时，
+// 可通过 Config.Verifier 替换验签逻辑
+type Verifier interface {
+	// Verify 验证签名
+	// digest 为签名字符串 SHA256 摘要的小写十六进制文本(64 字节)，sig 为 Base64 解码后的签名，
+	// 验证通过返回 nil
+	Verify(digest, sig []byte) error
+}
+
+// pemVerifier 使用平台 PEM 公钥验签的默认实现，公钥在首次验签时解析并缓存
+type pemVerifier struct {
+	keys *keyCache
+}
+
+func (v pemVerifier) Verify(digest, sig []byte) error {
+	publicKey, err := v.keys.getPublicKey()
+	if err != nil {
+		return newLocalizedError(ErrKeyParse, msgParsePublicKey, err)
+	}
+	return verifyDigest(publicKey, digest, sig)
+}
+
+// NewPEMVerifier 创建使用 PEM 公钥验签的验证器
+// 与未配置 Config.Verifier 时的默认行为一致
+//
+// 参数:
+//   - publicKeyPEM: 平台公钥（支持纯公钥字符串或完整PEM格式），在首次验签时解析
+//   - opts: 密钥选项，公钥默认至少为 DefaultMinKeyBits 位，可通过 WithMinKeyBits 放宽
+//
+// 返回:
+//   - Verifier: 验证器
+func NewPEMVerifier(publicKeyPEM string, opts ...KeyOption) Verifier {
+	return pemVerifier{keys: newKeyCache("", publicKeyPEM, minKeyBitsOf(opts))}
+}
+
+// rsaKeyVerifier 使用已解析的公钥验签
+type rsaKeyVerifier struct {
+	publicKey *rsa.PublicKey
+}
+
+func (v rsaKeyVerifier) Verify(digest, sig []byte) error {
+	return verifyDigest(v.publicKey, digest, sig)
+}
+
+// NewPublicKeyVerifier 创建使用已解析公钥验签的验证器
+//
+// 参数:
+//   - publicKey: RSA 公钥，例如从 KMS 获取后解析的公钥
+//
+// 返回:
+//   - Verifier: 验证器
+func NewPublicKeyVerifier(publicKey *rsa.PublicKey) Verifier {
+	return rsaKeyVerifier{publicKey: publicKey}
+}
+
+// anyVerifier 任一验证器验证通过即视为通过
+type anyVerifier []Verifier
+
+func (vs anyVerifier) Verify(digest, sig []byte) error {
+	errs := make([]error, 0, len(vs))
+	for _, v := range vs {
+		err := v.Verify(digest, sig)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return errors.New("no verifier configured")
+	}
+	return errors.Join(errs...)
+}
+
+// NewAnyVerifier 组合多个验证器，任一验证通过即视为通过
+// 适用于平台公钥轮换期间同时接受新旧公钥等允许多把公钥的场景
+//
+// 参数:
+//   - verifiers: 验证器列表，按顺序尝试
+//
+// 返回:
+//   - Verifier: 验证器，全部失败时返回所有验证器的错误
+//
+// 示例:
+//
+//	config.WithVerifier(sdk.NewAnyVerifier(
+//	    sdk.NewPEMVerifier(newPlatformPublicKeyPEM),
+//	    sdk.NewPEMVerifier(oldPlatformPublicKeyPEM),
+//	))
+func NewAnyVerifier(verifiers ...Verifier) Verifier {
+	return anyVerifier(verifiers)
+}
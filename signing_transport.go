@@ -0,0 +1,138 @@
+package haozpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// signingTransport 对 HaozPayRequest 报文签名的 http.RoundTripper
+type signingTransport struct {
+	base   http.RoundTripper
+	config *Config
+	signer Signer
+}
+
+// NewSigningTransport 创建对请求签名的 http.RoundTripper
+// 供不使用 SDK 客户端、自行管理 http.Client 的调用方复用签名逻辑，
+// 请求体为 HaozPayRequest 格式的 JSON(包含 bizBody 字段)时按与 SDK 客户端相同的规则签名，其他请求原样转发
+//
+// 参数:
+//   - base: 实际发送请求的传输层，为 nil 时使用 http.DefaultTransport
+//   - config: SDK 配置，使用其中的商户编号、私钥或 Signer、签名位置、NonceGenerator 和时钟
+//
+// 返回:
+//   - http.RoundTripper: 签名传输层，可设置为 http.Client.Transport
+//
+// 注意:
+//   - 签名后的请求体按 HaozPayRequest 重新序列化，报文中 HaozPayRequest 以外的字段不会发送
+//   - 报文缺少 merchantNo、timestamp 时使用配置的商户编号和当前时间补齐，配置了 NonceGenerator 且缺少 nonce 时生成随机数
+//   - 只负责请求签名，不校验响应签名、不重试，也不进行限流、压缩等客户端处理
+//   - 配置在创建后不应再修改；私钥在首次签名时解析，解析失败时 RoundTrip 返回错误
+//
+// 示例:
+//
+//	httpClient := &http.Client{Transport: sdk.NewSigningTransport(nil, config)}
+//	body, _ := json.Marshal(sdk.HaozPayRequest{BizBody: `{"orderNo":"ORDER123456"}`})
+//	resp, err := httpClient.Post(config.BaseURL+"/pay-core/payment/order/query", "application/json", bytes.NewReader(body))
+func NewSigningTransport(base http.RoundTripper, config *Config) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &signingTransport{base: base, config: config}
+	if config != nil {
+		keys := newKeyCache(config.PrivateKey, config.PlatFormPublicKey, config.minKeyBits())
+		t.signer = config.signer(keys)
+	}
+	return t
+}
+
+// RoundTrip 对请求签名后交给底层传输层发送
+// 按 http.RoundTripper 的约定不修改传入的请求，签名后的请求体和请求头写入请求副本
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody || !isJSONContentType(req.Header.Get("Content-Type")) {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+
+	haozReq, ok := decodeHaozPayRequest(body)
+	if !ok {
+		return t.base.RoundTrip(withBody(req, nil, body))
+	}
+	if t.config == nil {
+		return nil, errors.New("signing transport: config is nil")
+	}
+
+	if haozReq.MerchantNo == "" {
+		haozReq.MerchantNo = t.config.MerchantNo
+	}
+	if haozReq.Timestamp == 0 {
+		haozReq.Timestamp = t.config.now().UnixMilli()
+	}
+	if haozReq.Nonce == "" && t.config.NonceGenerator != nil {
+		haozReq.Nonce = t.config.NonceGenerator()
+	}
+
+	header := req.Header.Clone()
+	sign, err := signHaozPayRequest(req.Context(), haozReq, header, t.signer, t.config.Debug, t.config.SignatureLocation, t.config.SignHeaders)
+	if err != nil {
+		return nil, err
+	}
+	if t.config.SignatureLocation == SignatureInHeader {
+		header.Set(t.config.signatureHeader(), sign)
+		haozReq.Sign = ""
+	} else {
+		haozReq.Sign = sign
+	}
+
+	signed, err := json.Marshal(haozReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signed request: %w", err)
+	}
+	return t.base.RoundTrip(withBody(req, header, signed))
+}
+
+// decodeHaozPayRequest 将请求体解析为 HaozPayRequest，请求体不是包含 bizBody 字段的 JSON 对象时返回 false
+func decodeHaozPayRequest(body []byte) (*HaozPayRequest, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, false
+	}
+	if _, ok := fields["bizBody"]; !ok {
+		return nil, false
+	}
+	var haozReq HaozPayRequest
+	if err := json.Unmarshal(body, &haozReq); err != nil {
+		return nil, false
+	}
+	return &haozReq, true
+}
+
+// withBody 返回使用新请求体的请求副本，header 不为 nil 时同时替换请求头
+func withBody(req *http.Request, header http.Header, body []byte) *http.Request {
+	clone := req.Clone(req.Context())
+	if header != nil {
+		clone.Header = header
+	}
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	clone.ContentLength = int64(len(body))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return clone
+}
+
+// isJSONContentType 判断 Content-Type 是否为 JSON
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
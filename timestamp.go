@@ -0,0 +1,74 @@
+package haozpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Timestamp 网关返回的时间戳
+// 网关以毫秒级 Unix 时间戳表示时间，解析后可直接作为 time.Time 使用
+//
+// 注意:
+//   - 字段缺失、为 null、0 或空字符串时为零值，可用 IsZero 判断
+//   - 兼容字符串编码的毫秒数(如 "1735689600000")和 RFC 3339 格式的时间文本
+//   - 序列化为毫秒数，零值序列化为 0
+//
+// 示例:
+//
+//	if !refund.RefundFinishTime.IsZero() {
+//	    log.Printf("退款完成时间: %s", refund.RefundFinishTime.Format(time.DateTime))
+//	}
+type Timestamp struct {
+	time.Time
+}
+
+// UnixMilli 返回毫秒级 Unix 时间戳，零值返回 0
+func (t Timestamp) UnixMilli() int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Time.UnixMilli()
+}
+
+// MarshalJSON 序列化为毫秒数
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, t.UnixMilli(), 10), nil
+}
+
+// UnmarshalJSON 解析毫秒级 Unix 时间戳
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	text := string(b)
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &text); err != nil {
+			return err
+		}
+		if text == "" {
+			t.Time = time.Time{}
+			return nil
+		}
+		if parsed, err := time.Parse(time.RFC3339Nano, text); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+
+	millis, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s", b)
+	}
+	if millis == 0 {
+		t.Time = time.Time{}
+		return nil
+	}
+	t.Time = time.UnixMilli(millis)
+	return nil
+}
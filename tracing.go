@@ -0,0 +1,75 @@
+package haozpay
+
+import "context"
+
+// Tracer 链路追踪接口
+// SDK 不直接依赖 OpenTelemetry，使用方可通过少量适配代码接入 OpenTelemetry 或其他追踪系统
+// 每次接口调用创建一个 Span，名称为接口路径，例如 /pay-core/payment/order
+//
+// 示例:
+//
+//	type otelTracer struct{ tracer trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, sdk.Span) {
+//	    ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//	    return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ span trace.Span }
+//
+//	func (s otelSpan) SetAttribute(key string, value interface{}) {
+//	    s.span.SetAttributes(attribute.String(key, fmt.Sprint(value)))
+//	}
+//
+//	func (s otelSpan) RecordError(err error) {
+//	    s.span.RecordError(err)
+//	    s.span.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (s otelSpan) End() { s.span.End() }
+//
+//	config.WithTracer(otelTracer{tracer: otel.Tracer("haozpay")})
+type Tracer interface {
+	// Start 创建 Span，返回的上下文会用于发送请求，以便传播追踪信息
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span 一次接口调用对应的追踪区间
+type Span interface {
+	// SetAttribute 设置 Span 属性
+	SetAttribute(key string, value interface{})
+	// RecordError 记录错误并将 Span 状态设置为失败
+	RecordError(err error)
+	// End 结束 Span
+	End()
+}
+
+// Span 属性名称
+const (
+	// SpanAttrOperation 接口操作，例如 CreateOrder
+	SpanAttrOperation = "haozpay.operation"
+	// SpanAttrStatusCode HTTP 状态码
+	SpanAttrStatusCode = "http.status_code"
+	// SpanAttrCode 业务码，成功时为 0
+	SpanAttrCode = "haozpay.code"
+	// SpanAttrRequestID 网关返回的请求ID
+	SpanAttrRequestID = "haozpay.request_id"
+	// SpanAttrTraceID 业务系统透传的请求ID
+	SpanAttrTraceID = "haozpay.trace_id"
+)
+
+// noopTracer 未配置 Tracer 时使用的空实现
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+// noopSpan 空 Span
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+func (noopSpan) RecordError(error) {}
+
+func (noopSpan) End() {}
@@ -1,29 +1,113 @@
 package haozpay
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
 
 type Response struct {
 	Code      int         `json:"code"`
 	Message   string      `json:"message"`
 	Data      interface{} `json:"data,omitempty"`
 	RequestID string      `json:"request_id,omitempty"`
-	Timestamp int64       `json:"timestamp,omitempty"`
+	Timestamp Timestamp   `json:"timestamp,omitempty"`
+	Sign      string      `json:"sign,omitempty"`
+	// Details 错误详情，例如字段级的校验错误，结构由网关决定
+	Details json.RawMessage `json:"details,omitempty"`
+
+	// rawData 解析时保留的 data 字段原文，SDK 内部按目标类型再次解析
+	rawData json.RawMessage
+}
+
+// UnmarshalJSON 解析网关响应
+// code 兼容数字和字符串两种编码(如 0 和 "0")，统一转换为 int；缺失或为 null 时为 0
+func (r *Response) UnmarshalJSON(b []byte) error {
+	type plain Response
+	aux := struct {
+		*plain
+		Code json.RawMessage `json:"code"`
+		Data json.RawMessage `json:"data"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	code, err := parseResponseCode(aux.Code)
+	if err != nil {
+		return err
+	}
+	r.Code = code
+
+	r.rawData = aux.Data
+	r.Data = nil
+	if len(aux.Data) > 0 {
+		if err := json.Unmarshal(aux.Data, &r.Data); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// parseResponseCode 解析数字或字符串编码的业务码
+func parseResponseCode(raw json.RawMessage) (int, error) {
+	return parseJSONInt(raw, "response code")
+}
+
+// parseJSONInt 解析数字或字符串编码的整数，缺失、null 或空字符串时为 0
+// name 为字段名称，用于错误信息
+func parseJSONInt(raw json.RawMessage, name string) (int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+
+	var text string
+	if raw[0] == '"' {
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return 0, err
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return 0, nil
+		}
+	} else {
+		text = string(raw)
+	}
+
+	code, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %s: must be an integer", name, raw)
+	}
+	return code, nil
+}
+
+// HaozPayRequest 网关请求报文
+// 字段的 JSON 键名即网关约定的报文字段，签名中间件按相同的键名构建签名参数，修改标签会导致验签失败
+// Timestamp 必须序列化为数字而不是字符串，签名字符串中按十进制整数渲染
+// 签名写入请求头(SignatureInHeader)时 Sign 为空，请求体中不包含 sign 字段
 type HaozPayRequest struct {
+	// MerchantNo 商户编号
 	MerchantNo string `json:"merchantNo"`
-	Timestamp  int64  `json:"timestamp"`
-	BizBody    string `json:"bizBody"`
-	Sign       string `json:"sign"`
+	// Timestamp 请求时间戳(毫秒)
+	Timestamp int64 `json:"timestamp"`
+	// Nonce 请求随机数，配置了 Config.NonceGenerator 时填充并参与签名，否则不发送
+	Nonce string `json:"nonce,omitempty"`
+	// BizBody 业务参数序列化后的 JSON 字符串
+	BizBody string `json:"bizBody"`
+	// Sign 请求签名，由签名中间件填充
+	Sign string `json:"sign,omitempty"`
 }
 
 type CreatePaymentOrderRequest struct {
-	OrderTitle        string  `json:"orderTitle"`
-	OrderAmount       float64 `json:"orderAmount"`
+	OrderTitle        string  `json:"orderTitle" validate:"required"`
+	OrderAmount       float64 `json:"orderAmount" validate:"gt=0,amount"`
 	PayType           int     `json:"payType"`
 	UseHaozPayCashier bool    `json:"useHaozPayCashier"`
-	NotifyUrl         string  `json:"notifyUrl"`
-	RedirectUrl       string  `json:"redirectUrl,omitempty"`
+	NotifyUrl         string  `json:"notifyUrl" validate:"required,url"`
+	RedirectUrl       string  `json:"redirectUrl,omitempty" validate:"url"`
 }
 
 type PaymentOrderResponse struct {
@@ -35,69 +119,218 @@ type PaymentOrderResponse struct {
 	OrderAmount     float64 `json:"orderAmount"`
 	PayInfo         string  `json:"payInfo"`
 	MerchantOrderNo string  `json:"merchantOrderNo"`
+	// OrderStatus 订单状态，只在订单查询响应中返回
+	// 字段名和状态码未在平台文档中公布，以平台提供的订单查询接口说明为准，网关未返回时为 0
+	OrderStatus int `json:"orderStatus,omitempty"`
+}
+
+// QRCodeURL 返回用于生成支付二维码的链接
+// 扫码支付时 PayInfo 为二维码链接(http/https 或微信的 weixin:// 链接)，
+// 使用收银台表单等其他方式时 PayInfo 为 HTML 表单，无法生成二维码，此时返回错误，避免把表单内容渲染为二维码
+func (r *PaymentOrderResponse) QRCodeURL() (string, error) {
+	payInfo := strings.TrimSpace(r.PayInfo)
+	if payInfo == "" {
+		return "", &SDKError{
+			Code:       ErrInvalidResponse.Code,
+			Message:    "payInfo is empty, the channel did not return a QR code",
+			StatusCode: 0,
+			Operation:  OperationCreateOrder,
+		}
+	}
+
+	u, err := url.Parse(payInfo)
+	if err != nil || !qrCodeSchemes[u.Scheme] || (u.Host == "" && u.Opaque == "") {
+		return "", &SDKError{
+			Code:       ErrInvalidResponse.Code,
+			Message:    fmt.Sprintf("payInfo is not a QR code URL: %s", bodySnippet([]byte(payInfo))),
+			StatusCode: 0,
+			Operation:  OperationCreateOrder,
+		}
+	}
+	return payInfo, nil
+}
+
+// qrCodeSchemes 二维码链接允许的协议
+var qrCodeSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"weixin": true,
+}
+
+// VerifyAmount 校验订单金额与预期金额一致(单位：元)
+// 两者按分四舍五入后比较，避免浮点误差；不一致时返回 *AmountMismatchError，
+// 可在收到回调或查询订单后调用，防止订单金额被篡改后按错误的金额发货
+func (r *PaymentOrderResponse) VerifyAmount(expected float64) error {
+	if math.Round(r.OrderAmount*100) == math.Round(expected*100) {
+		return nil
+	}
+	return &AmountMismatchError{
+		MerchantOrderNo: r.MerchantOrderNo,
+		Expected:        expected,
+		Actual:          r.OrderAmount,
+	}
+}
+
+// QueryOrderRequest 订单查询请求
+// 订单由平台分配的订单号定位，下单请求中没有商户流水号，不能按流水号查询订单
+type QueryOrderRequest struct {
+	OrderNo string `json:"orderNo" validate:"required"`
 }
 
 type CancelPaymentOrderRequest struct {
-	OrderNo      string `json:"orderNo"`
+	OrderNo      string `json:"orderNo" validate:"required"`
 	CancelReason string `json:"cancelReason,omitempty"`
 }
 
 type CreateRefundRequest struct {
 	OrderNo      string  `json:"orderNo,omitempty"`
 	ReqSeqId     string  `json:"reqSeqId,omitempty"`
-	RefundAmount float64 `json:"refundAmount"`
+	RefundAmount float64 `json:"refundAmount" validate:"gt=0,amount"`
 	RefundReason string  `json:"refundReason,omitempty"`
 	Remark       string  `json:"remark,omitempty"`
-	NotifyUrl    string  `json:"notifyUrl,omitempty"`
+	NotifyUrl    string  `json:"notifyUrl,omitempty" validate:"url"`
 }
 
 type RefundResponse struct {
-	MerchantNo        string    `json:"merchantNo"`
-	OrderNo           string    `json:"orderNo"`
-	SeqId             string    `json:"seqId"`
-	ReqDate           string    `json:"reqDate"`
-	PaySeqId          string    `json:"paySeqId"`
-	PayReqDate        string    `json:"payReqDate"`
-	PayUniqueId       string    `json:"payUniqueId"`
-	RefundStartDate   string    `json:"refundStartDate"`
-	RefundStartTime   time.Time `json:"refundStartTime"`
-	RefundFinishTime  time.Time `json:"refundFinishTime"`
-	RefundStatus      int       `json:"refundStatus"`
-	RefundAmount      float64   `json:"refundAmount"`
-	RealRefundAmount  float64   `json:"realRefundAmount"`
-	TotalRefAmount    string    `json:"totalRefAmount"`
-	TotalRefFeeAmount string    `json:"totalRefFeeAmount"`
-	RefCount          string    `json:"refCount"`
+	MerchantNo        string       `json:"merchantNo"`
+	OrderNo           string       `json:"orderNo"`
+	SeqId             string       `json:"seqId"`
+	ReqDate           string       `json:"reqDate"`
+	PaySeqId          string       `json:"paySeqId"`
+	PayReqDate        string       `json:"payReqDate"`
+	PayUniqueId       string       `json:"payUniqueId"`
+	RefundStartDate   string       `json:"refundStartDate"`
+	RefundStartTime   Timestamp    `json:"refundStartTime"`
+	RefundFinishTime  Timestamp    `json:"refundFinishTime"`
+	RefundStatus      RefundStatus `json:"refundStatus"`
+	RefundAmount      float64      `json:"refundAmount"`
+	RealRefundAmount  float64      `json:"realRefundAmount"`
+	TotalRefAmount    string       `json:"totalRefAmount"`
+	TotalRefFeeAmount string       `json:"totalRefFeeAmount"`
+	RefCount          string       `json:"refCount"`
+}
+
+type BatchRefundRequest struct {
+	Items []*CreateRefundRequest `json:"items" validate:"required"`
+}
+
+type BatchRefundItemResult struct {
+	Index    int             `json:"index"`
+	OrderNo  string          `json:"orderNo"`
+	ReqSeqId string          `json:"reqSeqId"`
+	Success  bool            `json:"success"`
+	Code     int             `json:"code"`
+	Message  string          `json:"message"`
+	Refund   *RefundResponse `json:"refund,omitempty"`
+}
+
+type BatchRefundResponse struct {
+	SuccessCount int                      `json:"successCount"`
+	FailCount    int                      `json:"failCount"`
+	Results      []*BatchRefundItemResult `json:"results"`
 }
 
 type QueryRefundRequest struct {
-	OrderNo     string `json:"orderNo"`
+	OrderNo     string `json:"orderNo" validate:"required"`
 	RefundSeqId string `json:"refundSeqId,omitempty"`
 }
 
 type QueryRefundResponse struct {
-	MerchantNo         string  `json:"merchantNo"`
-	OrderNo            string  `json:"orderNo"`
-	RefundSeqId        string  `json:"refundSeqId"`
-	PaySeqId           string  `json:"paySeqId"`
-	PayReqDate         string  `json:"payReqDate"`
-	RefundAmount       float64 `json:"refundAmount"`
-	ActualRefundAmount float64 `json:"actualRefundAmount"`
-	RefundStatus       int     `json:"refundStatus"`
-	RefundStatusDesc   string  `json:"refundStatusDesc"`
-	TransFinishTime    string  `json:"transFinishTime"`
-	FeeAmount          float64 `json:"feeAmount"`
-	AcctSplitBunch     string  `json:"acctSplitBunch"`
-	UnconfirmAmount    float64 `json:"unconfirmAmount"`
-	ConfirmedAmount    float64 `json:"confirmedAmount"`
-	PayChannel         string  `json:"payChannel"`
-	Remark             string  `json:"remark"`
+	MerchantNo         string          `json:"merchantNo"`
+	OrderNo            string          `json:"orderNo"`
+	RefundSeqId        string          `json:"refundSeqId"`
+	PaySeqId           string          `json:"paySeqId"`
+	PayReqDate         string          `json:"payReqDate"`
+	RefundAmount       float64         `json:"refundAmount"`
+	ActualRefundAmount float64         `json:"actualRefundAmount"`
+	RefundStatus       RefundStatus    `json:"refundStatus"`
+	RefundStatusDesc   string          `json:"refundStatusDesc"`
+	TransFinishTime    string          `json:"transFinishTime"`
+	FeeAmount          float64         `json:"feeAmount"`
+	AcctSplitBunch     string          `json:"acctSplitBunch"`
+	UnconfirmAmount    float64         `json:"unconfirmAmount"`
+	ConfirmedAmount    float64         `json:"confirmedAmount"`
+	PayChannel         string          `json:"payChannel"`
+	Remark             string          `json:"remark"`
+	Records            []*RefundRecord `json:"records,omitempty"`
+}
+
+type RefundRecord struct {
+	RefundSeqId        string       `json:"refundSeqId"`
+	RefundAmount       float64      `json:"refundAmount"`
+	ActualRefundAmount float64      `json:"actualRefundAmount"`
+	RefundStatus       RefundStatus `json:"refundStatus"`
+	TransFinishTime    string       `json:"transFinishTime"`
+	ChannelRefundId    string       `json:"channelRefundId"`
+}
+
+// TotalRefunded 计算订单已成功退款的总金额(单位：元)
+// 累加 Records 中退款成功记录的实际退款金额；网关未返回 Records 时，按本次查询的退款记录计算。
+// 结果按分四舍五入，可用于校验累计退款金额不超过订单金额
+func (r *QueryRefundResponse) TotalRefunded() float64 {
+	var total float64
+	if len(r.Records) == 0 {
+		if r.RefundStatus.IsSuccess() {
+			total = r.ActualRefundAmount
+		}
+	} else {
+		for _, record := range r.Records {
+			if record != nil && record.RefundStatus.IsSuccess() {
+				total += record.ActualRefundAmount
+			}
+		}
+	}
+	return math.Round(total*100) / 100
+}
+
+// refundedOrPending 计算已占用订单可退金额的退款总额(单位：元)
+// 与 TotalRefunded 不同，初始和处理中的退款按申请金额计入，只有失败的退款不计入
+func (r *QueryRefundResponse) refundedOrPending() float64 {
+	amount := func(status RefundStatus, requested, actual float64) float64 {
+		switch status {
+		case RefundStatusSuccess:
+			return actual
+		case RefundStatusFailed:
+			return 0
+		default:
+			return requested
+		}
+	}
+
+	var total float64
+	if len(r.Records) == 0 {
+		if r.RefundSeqId != "" {
+			total = amount(r.RefundStatus, r.RefundAmount, r.ActualRefundAmount)
+		}
+	} else {
+		for _, record := range r.Records {
+			if record != nil {
+				total += amount(record.RefundStatus, record.RefundAmount, record.ActualRefundAmount)
+			}
+		}
+	}
+	return math.Round(total*100) / 100
 }
 
 type CreateWithdrawRequest struct {
-	PayChannel     string  `json:"payChannel"`
-	WithdrawAmount float64 `json:"withdrawAmount"`
-	ReqSeqId       string  `json:"reqSeqId"`
+	PayChannel     string  `json:"payChannel" validate:"required"`
+	WithdrawAmount float64 `json:"withdrawAmount" validate:"gt=0,amount"`
+	ReqSeqId       string  `json:"reqSeqId" validate:"required"`
 	Remark         string  `json:"remark,omitempty"`
-	NotifyUrl      string  `json:"notifyUrl,omitempty"`
+	NotifyUrl      string  `json:"notifyUrl,omitempty" validate:"url"`
+}
+
+type QueryRefundListRequest struct {
+	StartDate string `json:"startDate" validate:"required"`
+	EndDate   string `json:"endDate" validate:"required"`
+	OrderNo   string `json:"orderNo,omitempty"`
+	PageNum   int    `json:"pageNum" validate:"min=0"`
+	PageSize  int    `json:"pageSize" validate:"min=0"`
+}
+
+type RefundListResponse struct {
+	Total    int64                  `json:"total"`
+	PageNum  int                    `json:"pageNum"`
+	PageSize int                    `json:"pageSize"`
+	List     []*QueryRefundResponse `json:"list"`
 }
@@ -0,0 +1,201 @@
+package haozpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestResponseUnmarshalCode 业务码可以是数字或字符串，无法解析为整数时返回错误
+func TestResponseUnmarshalCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int
+		wantErr bool
+	}{
+		{name: "number", body: `{"code":200,"message":"ok"}`, want: 200},
+		{name: "string", body: `{"code":"200","message":"ok"}`, want: 200},
+		{name: "string with spaces", body: `{"code":" 4001 ","message":"bad"}`, want: 4001},
+		{name: "zero", body: `{"code":0}`, want: 0},
+		{name: "missing", body: `{"message":"ok"}`, want: 0},
+		{name: "null", body: `{"code":null}`, want: 0},
+		{name: "non-numeric string", body: `{"code":"OK"}`, wantErr: true},
+		{name: "float", body: `{"code":200.5}`, wantErr: true},
+		{name: "boolean", body: `{"code":true}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp Response
+			err := json.Unmarshal([]byte(tt.body), &resp)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = code %d, want error", tt.body, resp.Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s): %v", tt.body, err)
+			}
+			if resp.Code != tt.want {
+				t.Fatalf("Code = %d, want %d", resp.Code, tt.want)
+			}
+		})
+	}
+}
+
+// TestResponseUnmarshalData data 字段原文保留，供按目标类型再次解析
+func TestResponseUnmarshalData(t *testing.T) {
+	var resp Response
+	body := `{"code":"0","message":"ok","data":{"seqId":"S1","orderAmount":1.50},"request_id":"rq1","sign":"abc"}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if resp.Code != 0 || resp.Message != "ok" || resp.RequestID != "rq1" || resp.Sign != "abc" {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if got := string(resp.rawData); got != `{"seqId":"S1","orderAmount":1.50}` {
+		t.Fatalf("rawData = %s", got)
+	}
+}
+
+// TestHaozPayRequestWireFormat 网关报文的键名和类型是与网关的约定，签名中间件依赖相同的键名
+func TestHaozPayRequestWireFormat(t *testing.T) {
+	req := HaozPayRequest{
+		MerchantNo: "M1",
+		Timestamp:  1700000000000,
+		Nonce:      "n1",
+		BizBody:    `{"orderNo":"ORDER001"}`,
+		Sign:       "s1",
+	}
+	raw, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"merchantNo":"M1","timestamp":1700000000000,"nonce":"n1","bizBody":"{\"orderNo\":\"ORDER001\"}","sign":"s1"}`
+	if string(raw) != want {
+		t.Fatalf("Marshal = %s\nwant %s", raw, want)
+	}
+
+	// timestamp 必须是 JSON 数字而不是字符串
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatalf("Unmarshal fields: %v", err)
+	}
+	if ts := fields["timestamp"]; len(ts) == 0 || ts[0] == '"' {
+		t.Fatalf("timestamp = %s, want a JSON number", ts)
+	}
+
+	var back HaozPayRequest
+	if err := json.Unmarshal(raw, &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if back != req {
+		t.Fatalf("round trip = %+v, want %+v", back, req)
+	}
+
+	// 未设置 nonce 和 sign(签名写入请求头)时不发送这两个字段
+	raw, err = json.Marshal(HaozPayRequest{MerchantNo: "M1", Timestamp: 1, BizBody: "{}"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if got := jsonKeys(t, raw); !reflect.DeepEqual(got, []string{"bizBody", "merchantNo", "timestamp"}) {
+		t.Fatalf("keys = %v, want [bizBody merchantNo timestamp]", got)
+	}
+}
+
+// TestBizTypesWireKeys 业务请求序列化后的键名即 bizBody 中的字段名，也是签名参数名
+func TestBizTypesWireKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{
+			name: "CreatePaymentOrderRequest",
+			value: CreatePaymentOrderRequest{
+				OrderTitle: "t", OrderAmount: 1.5, PayType: 1, UseHaozPayCashier: true,
+				NotifyUrl: "https://n", RedirectUrl: "https://r",
+			},
+			want: []string{"notifyUrl", "orderAmount", "orderTitle", "payType", "redirectUrl", "useHaozPayCashier"},
+		},
+		{
+			name:  "QueryOrderRequest",
+			value: QueryOrderRequest{OrderNo: "O1"},
+			want:  []string{"orderNo"},
+		},
+		{
+			name: "CreateRefundRequest",
+			value: CreateRefundRequest{
+				OrderNo: "O1", ReqSeqId: "R1", RefundAmount: 1, RefundReason: "r", Remark: "m", NotifyUrl: "https://n",
+			},
+			want: []string{"notifyUrl", "orderNo", "refundAmount", "refundReason", "remark", "reqSeqId"},
+		},
+		{
+			name:  "QueryRefundRequest",
+			value: QueryRefundRequest{OrderNo: "O1", RefundSeqId: "F1"},
+			want:  []string{"orderNo", "refundSeqId"},
+		},
+		{
+			name:  "QueryRefundListRequest",
+			value: QueryRefundListRequest{StartDate: "20240101", EndDate: "20240102", OrderNo: "O1", PageNum: 1, PageSize: 10},
+			want:  []string{"endDate", "orderNo", "pageNum", "pageSize", "startDate"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if got := jsonKeys(t, raw); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("keys = %v, want %v", got, tt.want)
+			}
+
+			// 反序列化回原类型后应与原值一致
+			back := reflect.New(reflect.TypeOf(tt.value))
+			if err := json.Unmarshal(raw, back.Interface()); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(back.Elem().Interface(), tt.value) {
+				t.Fatalf("round trip = %+v, want %+v", back.Elem().Interface(), tt.value)
+			}
+		})
+	}
+}
+
+// TestPaymentOrderResponseWireKeys 订单响应按网关的键名解析
+func TestPaymentOrderResponseWireKeys(t *testing.T) {
+	body := []byte(`{"merchantNo":"M1","channelType":"WX","seqId":"S1","payType":2,"orderTitle":"t",` +
+		`"orderAmount":1.5,"payInfo":"weixin://x","merchantOrderNo":"MO1","orderStatus":1}`)
+	var got PaymentOrderResponse
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := PaymentOrderResponse{
+		MerchantNo: "M1", ChannelType: "WX", SeqId: "S1", PayType: 2, OrderTitle: "t",
+		OrderAmount: 1.5, PayInfo: "weixin://x", MerchantOrderNo: "MO1", OrderStatus: 1,
+	}
+	if got != want {
+		t.Fatalf("decoded = %+v, want %+v", got, want)
+	}
+}
+
+// jsonKeys 返回 JSON 对象按字典序排列的键名
+func jsonKeys(t *testing.T, raw []byte) []string {
+	t.Helper()
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,123 @@
+package haozpay
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// validateStruct 按字段的 validate 标签校验请求参数，返回第一个校验失败的原因，校验通过时返回空字符串
+// doRequest 在序列化和签名之前对所有业务请求调用，只检查结构体(或其指针)的直接字段，其他类型不校验
+//
+// 支持的规则(多个规则以逗号分隔，按顺序检查):
+//   - required: 不能为零值，字符串去除首尾空白后不能为空
+//   - gt=N: 数字必须大于 N
+//   - min=N、max=N: 数字的取值范围，字符串按字符数、切片按元素数计算
+//   - amount: 金额以元为单位，最多保留两位小数
+//   - url: 非空时必须是 http 或 https 的绝对地址
+//
+// 字段间的关联校验(如 OrderNo 和 ReqSeqId 至少指定一个)不适合用标签表达，仍由各接口单独校验
+func validateStruct(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ""
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			if msg := checkRule(field.Name, rv.Field(i), name, param); msg != "" {
+				return msg
+			}
+		}
+	}
+	return ""
+}
+
+// checkRule 使用单条规则校验字段
+func checkRule(name string, value reflect.Value, rule, param string) string {
+	var limit float64
+	switch rule {
+	case "gt", "min", "max":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return fmt.Sprintf("%s has invalid validate rule %s=%s", name, rule, param)
+		}
+		limit = n
+	}
+
+	switch rule {
+	case "required":
+		if value.Kind() == reflect.String {
+			if strings.TrimSpace(value.String()) == "" {
+				return fmt.Sprintf("%s is required", name)
+			}
+		} else if value.IsZero() {
+			return fmt.Sprintf("%s is required", name)
+		}
+	case "gt":
+		if n, ok := numberValue(value); ok && (math.IsNaN(n) || n <= limit) {
+			return fmt.Sprintf("%s must be greater than %s", name, param)
+		}
+	case "min":
+		if n, unit, ok := sizeValue(value); ok && n < limit {
+			return fmt.Sprintf("%s must be at least %s%s", name, param, unit)
+		}
+	case "max":
+		if n, unit, ok := sizeValue(value); ok && n > limit {
+			return fmt.Sprintf("%s must be at most %s%s", name, param, unit)
+		}
+	case "amount":
+		if n, ok := numberValue(value); ok {
+			if cents := n * 100; math.IsNaN(n) || math.Abs(cents-math.Round(cents)) > 1e-6 {
+				return fmt.Sprintf("%s must have at most 2 decimal places", name)
+			}
+		}
+	case "url":
+		if value.Kind() == reflect.String && value.String() != "" {
+			return validateCallbackURL(name, value.String())
+		}
+	default:
+		return fmt.Sprintf("%s has unknown validate rule %q", name, rule)
+	}
+	return ""
+}
+
+// numberValue 返回数字字段的值，非数字字段返回 false
+func numberValue(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+// sizeValue 返回 min、max 规则比较的值：数字为其值，字符串为字符数，切片为元素数
+func sizeValue(value reflect.Value) (float64, string, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(len([]rune(value.String()))), " characters", true
+	case reflect.Slice, reflect.Map:
+		return float64(value.Len()), " items", true
+	}
+	n, ok := numberValue(value)
+	return n, "", ok
+}
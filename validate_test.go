@@ -0,0 +1,124 @@
+package haozpay
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestValidateStruct 按规则逐条检查 validate 标签的校验结果
+func TestValidateStruct(t *testing.T) {
+	type required struct {
+		S string            `validate:"required"`
+		N int               `validate:"required"`
+		L []string          `validate:"required"`
+		M map[string]string `validate:"required"`
+	}
+	type gt struct {
+		F float64 `validate:"gt=0"`
+		I int     `validate:"gt=10"`
+	}
+	type amount struct {
+		F float64 `validate:"amount"`
+	}
+	type minMax struct {
+		S string   `validate:"min=2,max=4"`
+		N int      `validate:"min=1,max=3"`
+		L []string `validate:"max=1"`
+	}
+	type link struct {
+		U string `validate:"url"`
+	}
+	validRequired := required{S: "x", N: 1, L: []string{"a"}, M: map[string]string{"k": "v"}}
+
+	tests := []struct {
+		name string
+		v    interface{}
+		// want 为空时期望校验通过，否则期望错误信息包含该文本
+		want string
+	}{
+		{name: "required all set", v: validRequired},
+		{name: "required empty string", v: required{N: 1, L: []string{"a"}, M: validRequired.M}, want: "S is required"},
+		{name: "required whitespace-only string", v: required{S: " \t\n", N: 1, L: []string{"a"}, M: validRequired.M}, want: "S is required"},
+		{name: "required zero int", v: required{S: "x", L: []string{"a"}, M: validRequired.M}, want: "N is required"},
+		{name: "required nil slice", v: required{S: "x", N: 1, M: validRequired.M}, want: "L is required"},
+		{name: "required nil map", v: required{S: "x", N: 1, L: []string{"a"}}, want: "M is required"},
+
+		{name: "gt above limit", v: gt{F: 0.01, I: 11}},
+		{name: "gt equal to limit", v: gt{F: 0, I: 11}, want: "F must be greater than 0"},
+		{name: "gt negative", v: gt{F: -1, I: 11}, want: "F must be greater than 0"},
+		{name: "gt NaN", v: gt{F: math.NaN(), I: 11}, want: "F must be greater than 0"},
+		{name: "gt int", v: gt{F: 1, I: 10}, want: "I must be greater than 10"},
+
+		{name: "amount whole yuan", v: amount{F: 100}},
+		{name: "amount two decimals", v: amount{F: 0.01}},
+		{name: "amount float rounding", v: amount{F: 0.1 + 0.2}},
+		{name: "amount large with two decimals", v: amount{F: 12345678.99}},
+		{name: "amount three decimals", v: amount{F: 1.005}, want: "F must have at most 2 decimal places"},
+		{name: "amount NaN", v: amount{F: math.NaN()}, want: "F must have at most 2 decimal places"},
+
+		{name: "min max within range", v: minMax{S: "ab", N: 1}},
+		{name: "min counts runes", v: minMax{S: "支付", N: 1}},
+		{name: "max counts runes", v: minMax{S: "皓臻支付", N: 1}},
+		{name: "min string too short", v: minMax{S: "支", N: 1}, want: "S must be at least 2 characters"},
+		{name: "max string too long", v: minMax{S: "皓臻支付平", N: 1}, want: "S must be at most 4 characters"},
+		{name: "min number", v: minMax{S: "ab", N: 0}, want: "N must be at least 1"},
+		{name: "max number", v: minMax{S: "ab", N: 4}, want: "N must be at most 3"},
+		{name: "max slice", v: minMax{S: "ab", N: 1, L: []string{"a", "b"}}, want: "L must be at most 1 items"},
+
+		{name: "url empty", v: link{}},
+		{name: "url https", v: link{U: "https://example.com/notify"}},
+		{name: "url relative", v: link{U: "/notify"}, want: "U must be an absolute http or https URL"},
+		{name: "url other scheme", v: link{U: "ftp://example.com/notify"}, want: "U must be an absolute http or https URL"},
+
+		{name: "unknown rule", v: struct {
+			S string `validate:"requird"`
+		}{S: "x"}, want: `S has unknown validate rule "requird"`},
+		{name: "malformed parameter", v: struct {
+			N int `validate:"gt=abc"`
+		}{N: 1}, want: "N has invalid validate rule gt=abc"},
+		{name: "missing parameter", v: struct {
+			N int `validate:"min"`
+		}{N: 1}, want: "N has invalid validate rule min="},
+		{name: "rules checked in order", v: struct {
+			F float64 `validate:"gt=0,amount"`
+		}{F: -0.001}, want: "F must be greater than 0"},
+		{name: "unexported field skipped", v: struct {
+			s string `validate:"required"`
+		}{}},
+
+		{name: "pointer to struct", v: &required{}, want: "S is required"},
+		{name: "nil pointer", v: (*required)(nil)},
+		{name: "non-struct", v: map[string]interface{}{"orderAmount": -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateStruct(tt.v)
+			if tt.want == "" {
+				if got != "" {
+					t.Fatalf("validateStruct = %q, want pass", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Fatalf("validateStruct = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateStructRequestTypes 业务请求类型的标签应能通过校验，且校验失败时给出字段名
+func TestValidateStructRequestTypes(t *testing.T) {
+	order := &CreatePaymentOrderRequest{
+		OrderTitle:  "商品",
+		OrderAmount: 0.01,
+		NotifyUrl:   "https://example.com/notify",
+	}
+	if msg := validateStruct(order); msg != "" {
+		t.Fatalf("valid order rejected: %s", msg)
+	}
+	order.OrderAmount = 0.015
+	if msg := validateStruct(order); !strings.Contains(msg, "OrderAmount") {
+		t.Fatalf("invalid amount: got %q", msg)
+	}
+}